@@ -0,0 +1,134 @@
+package prg2p
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Acronyms is the mode of transcribing acronyms and spelling words letter by
+// letter.
+type Acronyms int
+
+const (
+	// AcronymsWord transcribes all words, acronyms included, as they are
+	// written.
+	AcronymsWord Acronyms = iota
+
+	// AcronymsAuto spells words written in upper case letter by letter
+	// unless they are known to be read as words, like "NATO".
+	AcronymsAuto
+
+	// AcronymsSpell spells all words letter by letter.
+	AcronymsSpell
+)
+
+// letterNames are Polish names of letters used to spell words.
+var letterNames = map[rune]string{
+	'a': "a", 'ą': "ą", 'b': "be", 'c': "ce", 'ć': "cie", 'd': "de",
+	'e': "e", 'ę': "ę", 'f': "ef", 'g': "gie", 'h': "ha", 'i': "i",
+	'j': "jot", 'k': "ka", 'l': "el", 'ł': "eł", 'm': "em", 'n': "en",
+	'ń': "eń", 'o': "o", 'ó': "u", 'p': "pe", 'q': "ku", 'r': "er",
+	's': "es", 'ś': "eś", 't': "te", 'u': "u", 'v': "fau", 'w': "wu",
+	'x': "iks", 'y': "igrek", 'z': "zet", 'ź': "ziet", 'ż': "żet",
+}
+
+// acronymWords are acronyms read as words rather than spelled.
+var acronymWords = []string{
+	"nato", "nasa", "unesco", "uefa", "fifa", "pesel", "zus", "gus", "mon",
+	"pit", "krus", "pap", "nik", "cbos", "pekao", "ikea",
+}
+
+// WithAcronyms sets the mode of transcribing acronyms. AcronymsWord is used
+// by default.
+func WithAcronyms(mode Acronyms) Option {
+	return func(g *G2P) {
+		g.acronyms = mode
+	}
+}
+
+// WithAcronymWords adds words to the list of acronyms that AcronymsAuto
+// reads as words rather than spells. Words are compared after case folding
+// and normalization.
+func WithAcronymWords(words ...string) Option {
+	return func(g *G2P) {
+		g.extraWords = append(g.extraWords, words...)
+	}
+}
+
+// ParseAcronyms returns the acronym mode named s: "word", "auto" or "spell".
+func ParseAcronyms(s string) (Acronyms, error) {
+	switch s {
+	case "word":
+		return AcronymsWord, nil
+	case "auto":
+		return AcronymsAuto, nil
+	case "spell":
+		return AcronymsSpell, nil
+	}
+	return AcronymsWord, fmt.Errorf("unknown acronym mode %s", s)
+}
+
+// String returns the name of the acronym mode.
+func (a Acronyms) String() string {
+	switch a {
+	case AcronymsAuto:
+		return "auto"
+	case AcronymsSpell:
+		return "spell"
+	}
+	return "word"
+}
+
+// spells reports whether the word w with the case class c and the prepared
+// form p is spelled letter by letter.
+func (g *G2P) spells(c Case, p string) bool {
+	switch g.acronyms {
+	case AcronymsSpell:
+		return true
+	case AcronymsAuto:
+		return c == Upper && !g.readAsWord[p]
+	}
+	return false
+}
+
+// spell returns transcripts of the prepared word w spelled letter by letter
+// with names of its letters. Letters without a name are transcribed on their
+// own and other characters are skipped.
+func (g *G2P) spell(ctx context.Context, w string) ([]string, error) {
+	var trans [][]string
+	for _, r := range w {
+		name, ok := letterNames[r]
+		switch {
+		case ok:
+		case unicode.IsLetter(r):
+			name = string(r)
+		default:
+			continue
+		}
+		ts, err := g.transcribe(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to spell %s: %w", w, err)
+		}
+		trans = append(trans, ts)
+	}
+	return g.all(ctx, trans, 0)
+}
+
+// spellingKey returns the cache key of the prepared word w spelled letter by
+// letter, which never collides with words transcribed as they are written.
+func spellingKey(w string) string {
+	return "\x00" + w
+}
+
+// readAsWords returns the set of prepared acronyms read as words.
+func (g *G2P) readAsWords() map[string]bool {
+	out := make(map[string]bool)
+	for _, ws := range [][]string{acronymWords, g.extraWords} {
+		for _, w := range ws {
+			out[strings.TrimSpace(g.prepare(w))] = true
+		}
+	}
+	return out
+}
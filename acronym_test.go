@@ -0,0 +1,78 @@
+package prg2p
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test if acronyms are spelled letter by letter depending on the mode.
+func TestAcronyms(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		word string
+		want []string
+	}{
+		{"word", nil, "PKP", []string{"p k p"}},
+		{"auto", []Option{WithAcronyms(AcronymsAuto)}, "PKP", []string{"p e k a p e"}},
+		{"auto diacritics", []Option{WithAcronyms(AcronymsAuto)}, "ŁÓDŹ", []string{"e l_ u d e zi e t"}},
+		{"auto exception", []Option{WithAcronyms(AcronymsAuto)}, "NATO", []string{"n a t o"}},
+		{"auto added exception", []Option{WithAcronyms(AcronymsAuto), WithAcronymWords("PEKAO", "Pis")}, "PIS", []string{"p i s"}},
+		{"auto lower", []Option{WithAcronyms(AcronymsAuto)}, "pkp", []string{"p k p"}},
+		{"auto title", []Option{WithAcronyms(AcronymsAuto)}, "Ala", []string{"a l a"}},
+		{"spell", []Option{WithAcronyms(AcronymsSpell)}, "NATO", []string{"e n a t e o"}},
+		{"spell lower", []Option{WithAcronyms(AcronymsSpell)}, "b-2", []string{"b e"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g2p, err := Load(Rules(), c.opts...)
+			if err != nil {
+				t.Fatalf("failed to create G2P transcriber: %s", err)
+			}
+			have, err := g2p.Transcribe(c.word, false)
+			if err != nil {
+				t.Fatalf("failed to transcribe %s: %s", c.word, err)
+			}
+			if ok := reflect.DeepEqual(have, c.want); !ok {
+				t.Errorf("have %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Test if spelled words are marked in results and cached apart from the
+// same words read as they are written.
+func TestAcronymsResult(t *testing.T) {
+	g2p, err := Load(Rules(), WithAcronyms(AcronymsAuto), WithCache(8))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	for _, w := range []string{"USA", "usa", "USA"} {
+		res, err := g2p.TranscribeWord(w, false)
+		if err != nil {
+			t.Fatalf("failed to transcribe %s: %s", w, err)
+		}
+		if spelled := w == "USA"; res.Spelled != spelled {
+			t.Errorf("%s: have spelled %t; want: %t", w, res.Spelled, spelled)
+		}
+		if res.Spelled && res.Variants[0] != "u e s a" {
+			t.Errorf("%s: have %v; want: [u e s a]", w, res.Variants)
+		}
+	}
+	if res, _ := g2p.TranscribeWord("123", false); res.Spelled {
+		t.Error("digits should not be spelled")
+	}
+}
+
+// Test if acronym modes are parsed from their names.
+func TestParseAcronyms(t *testing.T) {
+	for _, m := range []Acronyms{AcronymsWord, AcronymsAuto, AcronymsSpell} {
+		have, err := ParseAcronyms(m.String())
+		if err != nil || have != m {
+			t.Errorf("have %v, %v; want: %v", have, err, m)
+		}
+	}
+	if _, err := ParseAcronyms("loud"); err == nil {
+		t.Error("unknown mode should cause an error")
+	}
+}
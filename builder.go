@@ -0,0 +1,250 @@
+package prg2p
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Context is the left or right context of a rule built from variables and
+// literal sets with the functions below. It renders to a set expression of
+// the line format.
+//
+// Example:
+//
+//	prg2p.Union(prg2p.Var("SA"), prg2p.Set("j"))  // SA+(j)
+//	prg2p.Not(prg2p.Set("ł", "l"))               // -(ł, l)
+//	prg2p.Seq(prg2p.Set("b"), prg2p.Var("SA"))    // (b) SA
+type Context struct {
+	expr   string
+	simple bool // Whether expr is a name or a literal set.
+}
+
+// Any returns the context matching any character including the word
+// boundary.
+func Any() Context {
+	return Context{expr: wildcard, simple: true}
+}
+
+// End returns the context matching the word boundary.
+func End() Context {
+	return Set("$")
+}
+
+// Var returns the context matching values of the variable name.
+func Var(name string) Context {
+	return Context{expr: name, simple: true}
+}
+
+// Set returns the context matching any of the values vals.
+func Set(vals ...string) Context {
+	return Context{expr: "(" + strings.Join(vals, ", ") + ")", simple: true}
+}
+
+// Not returns the context matching any character but the ones matched by c.
+func Not(c Context) Context {
+	return Context{expr: "-" + c.group()}
+}
+
+// Union returns the context matching characters matched by any of cs.
+func Union(cs ...Context) Context {
+	return join(cs, "+")
+}
+
+// Intersect returns the context matching characters matched by all of cs.
+func Intersect(cs ...Context) Context {
+	return join(cs, "&")
+}
+
+// Minus returns the context matching characters matched by a but not by b.
+func Minus(a, b Context) Context {
+	return Context{expr: a.group() + "-" + b.group()}
+}
+
+// Seq returns the context spanning more than one character matched by cs in
+// reading order.
+func Seq(cs ...Context) Context {
+	var parts []string
+	for _, c := range cs {
+		parts = append(parts, c.group())
+	}
+	return Context{expr: strings.Join(parts, " ")}
+}
+
+// String returns the context as a set expression of the line format.
+func (c Context) String() string {
+	return c.expr
+}
+
+// group returns the expression of c enclosed in parentheses unless it is a
+// name or a literal set.
+func (c Context) group() string {
+	if c.simple {
+		return c.expr
+	}
+	return "(" + c.expr + ")"
+}
+
+// join returns the context combining cs with the operator op.
+func join(cs []Context, op string) Context {
+	var parts []string
+	for _, c := range cs {
+		parts = append(parts, c.group())
+	}
+	return Context{expr: strings.Join(parts, op)}
+}
+
+// NewRule returns the rule transcribing source as any of targets when it
+// comes between the contexts left and right.
+func NewRule(left Context, source string, right Context, targets ...string) RuleDef {
+	return RuleDef{Left: left.String(), Source: source, Right: right.String(), Targets: targets}
+}
+
+// WithPriority returns the rule r with the priority p.
+func (r RuleDef) WithPriority(p int) RuleDef {
+	r.Priority = p
+	return r
+}
+
+// Declare declares the variable name with the values vals.
+func (rs *RuleSet) Declare(name string, vals ...string) *RuleSet {
+	rs.Statements = append(rs.Statements, Statement{Var: &VarDef{Name: name, Values: vals}})
+	return rs
+}
+
+// DeclareExpr declares the variable name with values of the context c.
+func (rs *RuleSet) DeclareExpr(name string, c Context) *RuleSet {
+	rs.Statements = append(rs.Statements, Statement{Var: &VarDef{Name: name, Expr: c.String()}})
+	return rs
+}
+
+// Add appends the rules to the rule set. When rules match a word at the same
+// position, the one with the higher priority wins, then the one with the
+// longer source and then the one with the more specific context. The order in
+// which rules were added only breaks ties between otherwise equal rules in
+// favour of the rule added later.
+func (rs *RuleSet) Add(rules ...RuleDef) *RuleSet {
+	for _, r := range rules {
+		r := r
+		rs.Statements = append(rs.Statements, Statement{Rule: &r})
+	}
+	return rs
+}
+
+// AddMap maps the characters from of words to the characters to before they
+// are transcribed.
+func (rs *RuleSet) AddMap(from, to string) *RuleSet {
+	if rs.Map == nil {
+		rs.Map = make(map[string]string)
+	}
+	rs.Map[from] = to
+	return rs
+}
+
+// AddPhonology appends the phonological rule l in the A -> B / L _ R format.
+func (rs *RuleSet) AddPhonology(l string) *RuleSet {
+	rs.Statements = append(rs.Statements, Statement{Phonology: l})
+	return rs
+}
+
+// AddTest appends the test case of the word with its expected transcripts.
+func (rs *RuleSet) AddTest(word string, transcripts ...string) *RuleSet {
+	rs.Tests = append(rs.Tests, TestCase{Word: word, Transcripts: transcripts})
+	return rs
+}
+
+// Validate evaluates all statements of the rule set and returns an error
+// listing every variable, rule and phonological rule that is not valid, or
+// nil if the rule set can be compiled.
+func (rs *RuleSet) Validate() error {
+	i := newInterpreter()
+	var errs []error
+	check := func(kind string, n int, l string) {
+		if err := i.eval(l); err != nil {
+			errs = append(errs, fmt.Errorf("%s %d: %s: %w", kind, n+1, l, err))
+		}
+	}
+	for from, to := range rs.Map {
+		if _, _, err := parseMap(mapKeyword + " " + from + " = " + to); err != nil || strings.Contains(from, "=") {
+			errs = append(errs, fmt.Errorf("map %q: invalid characters to map", from))
+		}
+	}
+	var nincs, nvars, nrules, nphon int
+	for _, st := range rs.Statements {
+		switch {
+		case st.Include != "":
+			check("include", nincs, includeDirective+" "+strconv.Quote(st.Include))
+			nincs++
+		case st.Var != nil:
+			v := st.Var
+			val := v.Expr
+			if val == "" {
+				val = strings.Join(v.Values, ", ")
+			}
+			if v.Name == "" || strings.ContainsAny(v.Name, " \t=") {
+				errs = append(errs, fmt.Errorf("var %d: invalid name %q", nvars+1, v.Name))
+			} else {
+				check("var", nvars, v.Name+" = "+val)
+			}
+			nvars++
+		case st.Rule != nil:
+			r := st.Rule
+			if r.Source == "" || len(r.Targets) == 0 {
+				errs = append(errs, fmt.Errorf("rule %d: expected source and targets", nrules+1))
+			} else {
+				check("rule", nrules, r.String())
+			}
+			nrules++
+		case st.Phonology != "":
+			i.sect = phonologySection
+			check("phonology", nphon, st.Phonology)
+			i.sect = rulesSection
+			nphon++
+		}
+	}
+	for n, tc := range rs.Tests {
+		if _, err := parseTest(tc.String()); err != nil {
+			errs = append(errs, fmt.Errorf("test %d: %w", n+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Compile validates the rule set and returns a fully initialized G2P object
+// with its rules configured with opts.
+func (rs *RuleSet) Compile(opts ...Option) (*G2P, error) {
+	if err := rs.Validate(); err != nil {
+		return nil, err
+	}
+	return LoadRuleSet(rs, opts...)
+}
+
+// OpenRuleSet reads the rule set from the file name in the line format or,
+// if its extension is .json, .yaml or .yml, as JSON or YAML. Paths of
+// included files are made relative to the working directory so that the
+// rule set can be modified and compiled.
+func OpenRuleSet(name string) (*RuleSet, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rs *RuleSet
+	if isStructured(name) {
+		rs, err = DecodeRuleSet(f)
+	} else {
+		rs, err = ReadRuleSet(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for k, st := range rs.Statements {
+		if st.Include != "" && !filepath.IsAbs(st.Include) {
+			rs.Statements[k].Include = filepath.Join(filepath.Dir(name), st.Include)
+		}
+	}
+	return rs, nil
+}
@@ -0,0 +1,126 @@
+package prg2p
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test if contexts render to set expressions of the line format.
+func TestContextString(t *testing.T) {
+	cases := []struct {
+		have Context
+		want string
+	}{
+		{Any(), "*"},
+		{End(), "($)"},
+		{Var("SA"), "SA"},
+		{Set("a", "e"), "(a, e)"},
+		{Not(Var("SB")), "-SB"},
+		{Not(Union(Var("SA"), Var("SB"))), "-(SA+SB)"},
+		{Union(Var("SA"), Set("j")), "SA+(j)"},
+		{Intersect(Var("SP"), Not(Var("SD"))), "SP&(-SD)"},
+		{Minus(Var("SP"), Set("ł", "l")), "SP-(ł, l)"},
+		{Seq(Set("s"), Not(Var("SB"))), "(s) (-SB)"},
+	}
+	for _, c := range cases {
+		if c.have.String() != c.want {
+			t.Errorf("have %q; want: %q", c.have.String(), c.want)
+		}
+	}
+}
+
+// Test if a rule set built in memory compiles and transcribes words.
+func TestRuleSetCompile(t *testing.T) {
+	rs := &RuleSet{}
+	rs.Declare("ALL", "a", "b", "k", "o", "s", "t").
+		Declare("SB", "k", "s", "t").
+		DeclareExpr("SV", Minus(Var("ALL"), Var("SB"))).
+		Add(
+			NewRule(Any(), "a", Any(), "a"),
+			NewRule(Any(), "o", Any(), "o"),
+			NewRule(Any(), "k", Any(), "k"),
+			NewRule(Any(), "s", Any(), "s"),
+			NewRule(Any(), "t", Any(), "t"),
+			NewRule(Any(), "b", Any(), "b"),
+			NewRule(Any(), "b", Union(End(), Var("SB")), "p"),
+			NewRule(Set("o"), "b", End(), "p", "b").WithPriority(1),
+		).
+		AddTest("baba", "b a b a").
+		AddTest("bob", "b o p", "b o b").
+		AddTest("abta", "a p t a")
+	g, err := rs.Compile()
+	if err != nil {
+		t.Fatalf("failed to compile rule set: %s", err)
+	}
+	if err := g.Check(); err != nil {
+		t.Errorf("tests of the rule set failed: %s", err)
+	}
+}
+
+// Test if Validate reports every invalid statement.
+func TestRuleSetValidate(t *testing.T) {
+	rs := &RuleSet{}
+	rs.Declare("ALL", "a", "b").
+		Declare("", "x").
+		DeclareExpr("X", Union(Var("NOPE"), Var("ALL"))).
+		Add(
+			NewRule(Any(), "a", Any(), "a"),
+			NewRule(Var("MISSING"), "b", Any(), "b"),
+			NewRule(Any(), "", Any(), "b"),
+			NewRule(Union(Any(), Var("ALL")), "b", Any(), "b"),
+		).
+		AddPhonology("a b").
+		AddTest("ab")
+	err := rs.Validate()
+	if err == nil {
+		t.Fatal("invalid rule set should cause an error")
+	}
+	var prefixes []string
+	for _, l := range strings.Split(err.Error(), "\n") {
+		prefix, _, _ := strings.Cut(l, ":")
+		prefixes = append(prefixes, prefix)
+	}
+	want := []string{"var 2", "var 3", "rule 2", "rule 3", "rule 4", "phonology 1", "test 1"}
+	if ok := reflect.DeepEqual(prefixes, want); !ok {
+		t.Errorf("have %v; want: %v", prefixes, want)
+	}
+	if _, err := rs.Compile(); err == nil {
+		t.Error("invalid rule set should not compile")
+	}
+}
+
+// Test if a rule file opened as a rule set can be extended with rules and
+// compiled.
+func TestOpenRuleSet(t *testing.T) {
+	dir := t.TempDir()
+	base := "ALL = a, k, o, t\nEMPTY = *\n"
+	rules := "#include \"base.txt\"\nEMPTY\tk\tEMPTY\tk\nEMPTY\to\tEMPTY\to\nEMPTY\tt\tEMPTY\tt\n"
+	if err := os.WriteFile(filepath.Join(dir, "base.txt"), []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "rules.txt"), []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := OpenRuleSet(filepath.Join(dir, "rules.txt"))
+	if err != nil {
+		t.Fatalf("failed to open rule set: %s", err)
+	}
+	rs.Add(NewRule(Var("EMPTY"), "a", Var("EMPTY"), "a"))
+	g, err := rs.Compile()
+	if err != nil {
+		t.Fatalf("failed to compile rule set: %s", err)
+	}
+	have, err := g.Transcribe("kota", false)
+	if err != nil {
+		t.Fatalf("failed to transcribe: %s", err)
+	}
+	if ok := reflect.DeepEqual(have, []string{"k o t a"}); !ok {
+		t.Errorf("have %v; want: [k o t a]", have)
+	}
+	if _, err := OpenRuleSet(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("missing file should cause an error")
+	}
+}
@@ -0,0 +1,76 @@
+package prg2p
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cache keeps transcripts of the most recently transcribed words. It is safe
+// for concurrent use and a nil cache keeps nothing.
+type cache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // Most recently used words at the front.
+	items map[string]*list.Element
+}
+
+// entry is the word with its transcripts stored in the cache.
+type entry struct {
+	word        string
+	transcripts []string
+}
+
+// newCache returns the cache holding up to size words.
+func newCache(size int) *cache {
+	if size <= 0 {
+		return nil
+	}
+	return &cache{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get returns transcripts of the word w and reports whether it was cached.
+func (c *cache) get(w string) ([]string, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[w]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*entry).transcripts, true
+}
+
+// put stores transcripts ts of the word w evicting the least recently used
+// word if the cache is full.
+func (c *cache) put(w string, ts []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[w]; ok {
+		e.Value.(*entry).transcripts = ts
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[w] = c.order.PushFront(&entry{word: w, transcripts: ts})
+	if c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.items, last.Value.(*entry).word)
+	}
+}
+
+// clear removes all words from the cache.
+func (c *cache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+}
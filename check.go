@@ -0,0 +1,86 @@
+package prg2p
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TestCase is a word with all of its expected transcripts in the order
+// Transcribe returns them. Test cases are declared in rule files with the
+// @test directive followed by the word and transcripts separated with "|":
+//
+//	@test bok b o k | b o g
+type TestCase struct {
+	Word        string   `json:"word" yaml:"word"`
+	Transcripts []string `json:"transcripts" yaml:"transcripts"`
+}
+
+// String returns the test case in the format of the @test directive.
+func (tc TestCase) String() string {
+	return tc.Word + " " + strings.Join(tc.Transcripts, " | ")
+}
+
+// test is a test case declared at the line of the rule file.
+type test struct {
+	TestCase
+	file string
+	line int
+}
+
+// parseTest parses the argument of the @test directive.
+func parseTest(arg string) (TestCase, error) {
+	word, trans, _ := strings.Cut(arg, " ")
+	tc := TestCase{Word: word}
+	for _, t := range strings.Split(trans, "|") {
+		if t = strings.Join(strings.Fields(t), " "); t != "" {
+			tc.Transcripts = append(tc.Transcripts, t)
+		}
+	}
+	if tc.Word == "" || len(tc.Transcripts) == 0 {
+		return TestCase{}, fmt.Errorf("expected word and transcripts")
+	}
+	return tc, nil
+}
+
+// Tests returns test cases declared in the loaded rules.
+func (g *G2P) Tests() []TestCase {
+	if g.interp == nil {
+		return nil
+	}
+	var out []TestCase
+	for _, t := range g.interp.tests {
+		out = append(out, t.TestCase)
+	}
+	return out
+}
+
+// Check transcribes words of test cases declared in the loaded rules and
+// returns an error listing the test cases that failed, or nil if all of them
+// passed.
+func (g *G2P) Check() error {
+	if g.interp == nil {
+		return nil
+	}
+	var errs []error
+	for _, t := range g.interp.tests {
+		have, err := g.Transcribe(t.Word, true)
+		if err == nil && slices.Equal(have, t.Transcripts) {
+			continue
+		}
+		pos := fmt.Sprintf("line %d", t.line)
+		if t.file != "" {
+			pos = fmt.Sprintf("%s:%d", t.file, t.line)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: test %s: %w", pos, t.Word, err))
+			continue
+		}
+		errs = append(errs, fmt.Errorf(
+			"%s: test %s: have %s; want %s",
+			pos, t.Word, strings.Join(have, " | "), strings.Join(t.Transcripts, " | "),
+		))
+	}
+	return errors.Join(errs...)
+}
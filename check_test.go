@@ -0,0 +1,61 @@
+package prg2p
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test if the argument of the @test directive is parsed.
+func TestParseTest(t *testing.T) {
+	have, err := parseTest("bok  b o k |b  o g|")
+	if err != nil {
+		t.Fatalf("failed to parse test: %s", err)
+	}
+	want := TestCase{Word: "bok", Transcripts: []string{"b o k", "b o g"}}
+	if ok := reflect.DeepEqual(have, want); !ok {
+		t.Errorf("have %v; want: %v", have, want)
+	}
+	if have.String() != "bok b o k | b o g" {
+		t.Errorf("have %q; want: %q", have.String(), "bok b o k | b o g")
+	}
+	for _, arg := range []string{"", "bok", "bok |"} {
+		if _, err := parseTest(arg); err == nil {
+			t.Errorf("argument %q should cause an error", arg)
+		}
+	}
+}
+
+// Test if Check reports failed test cases with their line numbers.
+func TestCheck(t *testing.T) {
+	rules := `
+ALL = a, b, k, o
+EMPTY = *
+EMPTY	a	EMPTY	a
+EMPTY	b	EMPTY	b
+EMPTY	o	EMPTY	o
+EMPTY	k	EMPTY	k, g
+@test bok b o k | b o g
+@test kab k a b
+@test xyz x y z
+`
+	g, err := Load(strings.NewReader(rules))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	if n := len(g.Tests()); n != 3 {
+		t.Errorf("have %d tests; want 3", n)
+	}
+	err = g.Check()
+	if err == nil {
+		t.Fatal("failed tests should cause an error")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	want := []string{
+		"line 9: test kab: have k a b | g a b; want k a b",
+		"line 10: test xyz: failed to transcribe xyz: no rule for \"x\" at offset 0",
+	}
+	if ok := reflect.DeepEqual(lines, want); !ok {
+		t.Errorf("have %q; want: %q", lines, want)
+	}
+}
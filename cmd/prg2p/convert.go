@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mdm-code/prg2p"
+	"gopkg.in/yaml.v3"
+)
+
+const convertUsage = `prg2p convert - convert rule files between formats
+
+The prg2p convert command reads the rule FILE, or standard input if no FILE
+is given, and writes it to standard output in another format: the line format
+of rule files (rules), JSON (json) or YAML (yaml). The input format is
+guessed from the extension of FILE unless it is given with -f. Statements
+and comments keep their order, while blank lines are not converted.
+
+Usage:  prg2p convert [-h] [-f FORMAT] [-t FORMAT] [FILE]
+
+Options:
+	-h, --help  show this help message and exit
+	-f, --from  format of FILE: rules, json or yaml (default: by extension)
+	-t, --to    format of the output: rules, json or yaml (default: json)
+
+Example:
+	prg2p convert -t yaml rules.txt > rules.yaml
+`
+
+// Rule file formats known to the convert subcommand.
+const (
+	fmtRules = "rules"
+	fmtJSON  = "json"
+	fmtYAML  = "yaml"
+)
+
+// convertRules runs the rule file conversion subcommand with args.
+func convertRules(args []string) int {
+	var from, to string
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	fs.StringVar(&from, "f", "", "")
+	fs.StringVar(&from, "from", "", "")
+	fs.StringVar(&to, "t", fmtJSON, "")
+	fs.StringVar(&to, "to", fmtJSON, "")
+	fs.Usage = func() { fmt.Print(convertUsage) }
+	fs.Parse(args)
+
+	name := stdin
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		name = fs.Arg(0)
+	default:
+		fs.Usage()
+		return exitFailure
+	}
+	if from == "" {
+		from = guessFormat(name)
+	}
+	rs, err := readRuleSet(name, from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(name+": "+err.Error()))
+		return exitFailure
+	}
+	if err := writeRuleSet(os.Stdout, rs, to); err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	return exitSuccess
+}
+
+// guessFormat returns the format of the rule file name judging by its
+// extension.
+func guessFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return fmtJSON
+	case ".yaml", ".yml":
+		return fmtYAML
+	}
+	return fmtRules
+}
+
+// readRuleSet reads the rule set in the format from the file name.
+func readRuleSet(name, format string) (*prg2p.RuleSet, error) {
+	in, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	switch format {
+	case fmtRules:
+		return prg2p.ReadRuleSet(in)
+	case fmtJSON, fmtYAML:
+		return prg2p.DecodeRuleSet(in)
+	}
+	return nil, fmt.Errorf("unknown format %s", format)
+}
+
+// writeRuleSet writes the rule set rs in the format to w.
+func writeRuleSet(w io.Writer, rs *prg2p.RuleSet, format string) error {
+	switch format {
+	case fmtRules:
+		_, err := rs.WriteTo(w)
+		return err
+	case fmtJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rs)
+	case fmtYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(rs); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("unknown format %s", format)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mdm-code/prg2p"
+)
+
+const coverageUsage = `prg2p coverage - report rules exercised by a corpus
+
+The prg2p coverage command transcribes space-delimited words from each FILE,
+or standard input if no FILE is given, and reports rules that fired with the
+number of hits and example words, rules that never fired and the usage of
+variables referred to in rule contexts.
+
+Usage:  prg2p coverage [-h] [-r FILE] [-j] [FILE ...]
+
+Options:
+	-h, --help   show this help message and exit
+	-r, --rules  file with g2p rules (default: prg2p.Rules())
+	-j, --json   write the report in JSON (default: false)
+
+Example:
+	prg2p coverage -r rules.txt corpus.txt
+
+Output:
+	words 3, failed 0
+	fired 4/150
+		3  rules.txt:61: EMPTY  a  EMPTY  a  (ala, ma, kota)
+		...
+	never fired 146/150
+		rules.txt:62: EMPTY  c  EMPTY  c
+		...
+	variables
+		EMPTY  rules 120  fired 4  hits 7
+		...
+`
+
+// coverage runs the rule coverage subcommand with args.
+func coverage(args []string) int {
+	var (
+		path   string
+		asJSON bool
+	)
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	fs.StringVar(&path, "r", "", "")
+	fs.StringVar(&path, "rules", "", "")
+	fs.BoolVar(&asJSON, "j", false, "")
+	fs.BoolVar(&asJSON, "json", false, "")
+	fs.Usage = func() { fmt.Print(coverageUsage) }
+	fs.Parse(args)
+
+	g2p, err := load(path, prg2p.WithCoverage())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{stdin}
+	}
+	code := exitSuccess
+	for _, name := range files {
+		if err := exercise(g2p, name); err != nil {
+			fmt.Fprintf(os.Stderr, EOL(name+": "+err.Error()))
+			code = exitFailure
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	c := g2p.Coverage()
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(c)
+	} else {
+		writeCoverage(out, c)
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	return code
+}
+
+// exercise transcribes words from the file name so that rules used are
+// counted. Words that cannot be transcribed are counted as failed.
+func exercise(g2p *prg2p.G2P, name string) error {
+	in, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	s := bufio.NewScanner(in)
+	s.Split(bufio.ScanWords)
+	for s.Scan() {
+		g2p.Transcribe(s.Text(), false)
+	}
+	return s.Err()
+}
+
+// writeCoverage writes the coverage report c in a human-readable form to w.
+func writeCoverage(w io.Writer, c prg2p.Coverage) {
+	fired, unfired := c.Fired(), c.Unfired()
+	fmt.Fprintf(w, "words %d, failed %d\n", c.Words, c.Failed)
+	fmt.Fprintf(w, "fired %d/%d\n", len(fired), len(c.Rules))
+	for _, r := range fired {
+		seg := prg2p.Segment{Rule: r.Rule, File: r.File, Line: r.Line}
+		fmt.Fprintf(w, "\t%d\t%s\t(%s)\n", r.Hits, FSegment(seg), strings.Join(r.Examples, ", "))
+	}
+	fmt.Fprintf(w, "never fired %d/%d\n", len(unfired), len(c.Rules))
+	for _, r := range unfired {
+		seg := prg2p.Segment{Rule: r.Rule, File: r.File, Line: r.Line}
+		fmt.Fprintf(w, "\t%s\n", FSegment(seg))
+	}
+	fmt.Fprintf(w, "variables\n")
+	for _, v := range c.Vars {
+		fmt.Fprintf(w, "\t%s\trules %d\tfired %d\thits %d\n", v.Name, v.Rules, v.Fired, v.Hits)
+	}
+}
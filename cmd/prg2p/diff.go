@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mdm-code/prg2p"
+)
+
+const diffUsage = `prg2p diff - compare transcription behaviour of two rule files
+
+The prg2p diff command transcribes words from WORDS with both OLD and NEW rule
+files and reports words whose transcripts changed along with the variants
+added and removed and the rules responsible on each side. WORDS holds one
+word per line optionally followed by a tab- or space-separated frequency.
+Frequencies of words listed more than once are added up.
+
+Usage:  prg2p diff [-h] [-f] OLD NEW WORDS
+
+Options:
+	-h, --help  show this help message and exit
+	-f, --freq  sort changes by descending word frequency (default: false)
+
+Example:
+	prg2p diff -f old.txt new.txt words.txt
+
+Output:
+	bok	120
+		- b o k
+		+ b o g
+		old: old.txt:118: EMPTY  k  -(b, ż)  k
+		new: new.txt:233: EMPTY  k  END  k, g
+`
+
+// entry is a word from the word list with its optional frequency.
+type entry struct {
+	word string
+	freq int
+}
+
+// diff runs the diff subcommand with args.
+func diff(args []string) int {
+	var byFreq bool
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.BoolVar(&byFreq, "f", false, "")
+	fs.BoolVar(&byFreq, "freq", false, "")
+	fs.Usage = func() { fmt.Print(diffUsage) }
+	fs.Parse(args)
+
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return exitFailure
+	}
+	a, err := load(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(fs.Arg(0)+": "+err.Error()))
+		return exitFailure
+	}
+	b, err := load(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(fs.Arg(1)+": "+err.Error()))
+		return exitFailure
+	}
+	entries, err := readWords(fs.Arg(2))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(fs.Arg(2)+": "+err.Error()))
+		return exitFailure
+	}
+
+	freq := make(map[string]int)
+	var words []string
+	for _, e := range entries {
+		if _, ok := freq[e.word]; !ok {
+			words = append(words, e.word)
+		}
+		freq[e.word] += e.freq
+	}
+	changes := prg2p.Diff(a, b, words)
+	if byFreq {
+		sort.SliceStable(changes, func(i, j int) bool {
+			return freq[changes[i].Word] > freq[changes[j].Word]
+		})
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	for _, c := range changes {
+		writeChange(out, c, freq[c.Word])
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	return exitSuccess
+}
+
+// writeChange writes the change c of a word with the frequency freq to w.
+func writeChange(w io.Writer, c prg2p.Change, freq int) {
+	fmt.Fprintf(w, "%s\t%d\n", c.Word, freq)
+	for _, v := range c.Removed {
+		fmt.Fprintf(w, "\t- %s\n", v)
+	}
+	for _, v := range c.Added {
+		fmt.Fprintf(w, "\t+ %s\n", v)
+	}
+	if c.OldErr != nil {
+		fmt.Fprintf(w, "\told: %s\n", c.OldErr)
+	}
+	if c.NewErr != nil {
+		fmt.Fprintf(w, "\tnew: %s\n", c.NewErr)
+	}
+	for _, s := range c.OldRules {
+		fmt.Fprintf(w, "\told: %s\n", FSegment(s))
+	}
+	for _, s := range c.NewRules {
+		fmt.Fprintf(w, "\tnew: %s\n", FSegment(s))
+	}
+}
+
+// readWords reads a word list with an optional frequency column from the
+// file at path.
+func readWords(path string) ([]entry, error) {
+	f, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []entry
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		fields := strings.Fields(s.Text())
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			out = append(out, entry{word: fields[0]})
+		case 2:
+			freq, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid frequency on line %d: %s", n, fields[1])
+			}
+			out = append(out, entry{word: fields[0], freq: freq})
+		default:
+			return nil, fmt.Errorf("expected word and frequency on line %d", n)
+		}
+	}
+	return out, s.Err()
+}
+
+// FSegment collates the rule of the segment s with its file name and line
+// number.
+func FSegment(s prg2p.Segment) string {
+	rule := strings.ReplaceAll(s.Rule, "\t", "  ")
+	if s.File != "" {
+		return fmt.Sprintf("%s:%d: %s", s.File, s.Line, rule)
+	}
+	return fmt.Sprintf("line %d: %s", s.Line, rule)
+}
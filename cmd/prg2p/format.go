@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mdm-code/prg2p"
+)
+
+const fmtUsage = `prg2p fmt - rewrite rule files in the canonical form
+
+The prg2p fmt command rewrites each rule FILE in place with aligned columns,
+normalised lists and sorted variable values where their order does not
+matter. Comments and the order of sections are preserved. With no FILE, rules
+are read from standard input and written to standard output.
+
+Usage:  prg2p fmt [-h] [-c] [FILE ...]
+
+Options:
+	-h, --help   show this help message and exit
+	-c, --check  list files that are not formatted and exit with a non-zero
+	             status instead of rewriting them (default: false)
+
+Example:
+	prg2p fmt --check rules/*.txt
+`
+
+// format runs the rule file formatter subcommand with args.
+func format(args []string) int {
+	var check bool
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	fs.BoolVar(&check, "c", false, "")
+	fs.BoolVar(&check, "check", false, "")
+	fs.Usage = func() { fmt.Print(fmtUsage) }
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{stdin}
+	}
+	code := exitSuccess
+	for _, name := range files {
+		changed, err := formatFile(name, check)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, EOL(name+": "+err.Error()))
+			code = exitFailure
+			continue
+		}
+		if check && changed {
+			fmt.Print(EOL(name))
+			code = exitFailure
+		}
+	}
+	return code
+}
+
+// formatFile formats the rule file name and reports whether its contents
+// changed. The file is rewritten unless check is set. Standard input is
+// formatted to standard output.
+func formatFile(name string, check bool) (bool, error) {
+	in, err := open(name)
+	if err != nil {
+		return false, err
+	}
+	src, err := io.ReadAll(in)
+	in.Close()
+	if err != nil {
+		return false, err
+	}
+	out, err := prg2p.Format(src)
+	if err != nil {
+		return false, err
+	}
+	changed := !bytes.Equal(src, out)
+	switch {
+	case check:
+	case name == stdin:
+		_, err = os.Stdout.Write(out)
+	case changed:
+		err = os.WriteFile(name, out, 0o644)
+	}
+	return changed, err
+}
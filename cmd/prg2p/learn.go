@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mdm-code/prg2p/learn"
+)
+
+const learnUsage = `prg2p learn - induce g2p rules from a pronunciation lexicon
+
+The prg2p learn command aligns letters of words from LEXICON with their
+phonemes and proposes rules in the rule file format. LEXICON holds one word
+per line followed by a tab and space-separated phonemes. A share of words is
+held out and the coverage and accuracy of the rules on them is reported on
+standard error. Contexts are expressed with variables declared in the rule
+file given with -v whenever possible.
+
+Usage:  prg2p learn [-h] [-v FILE] [-t RATIO] [-o FILE] [-m N] LEXICON
+
+Options:
+	-h, --help     show this help message and exit
+	-v, --vars     rule file with variables (default: prg2p.Rules())
+	-t, --holdout  share of words held out for evaluation (default: 0.1)
+	-o, --output   write rules to FILE instead of standard output
+	-m, --min      fewest occurrences to propose a rule (default: 2)
+
+Example:
+	prg2p learn -o dialect.txt lexicon.tsv
+	prg2p -r dialect.txt words.txt
+`
+
+// learnRules runs the rule induction subcommand with args.
+func learnRules(args []string) int {
+	var (
+		vars, output string
+		holdout      float64
+		minCount     int
+	)
+	fs := flag.NewFlagSet("learn", flag.ExitOnError)
+	fs.StringVar(&vars, "v", "", "")
+	fs.StringVar(&vars, "vars", "", "")
+	fs.Float64Var(&holdout, "t", 0.1, "")
+	fs.Float64Var(&holdout, "holdout", 0.1, "")
+	fs.StringVar(&output, "o", "", "")
+	fs.StringVar(&output, "output", "", "")
+	fs.IntVar(&minCount, "m", 2, "")
+	fs.IntVar(&minCount, "min", 2, "")
+	fs.Usage = func() { fmt.Print(learnUsage) }
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitFailure
+	}
+	g2p, err := load(vars)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	in, err := open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	lex, err := learn.ReadLexicon(in)
+	in.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(fs.Arg(0)+": "+err.Error()))
+		return exitFailure
+	}
+
+	opts := learn.Options{MinCount: minCount, Vars: g2p.Vars()}
+	rules, report, err := learn.Learn(lex, holdout, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	if output == "" {
+		_, err = io.WriteString(os.Stdout, rules.Format())
+	} else {
+		err = os.WriteFile(output, []byte(rules.Format()), 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	fmt.Fprint(os.Stderr, EOL(report.String()))
+	return exitSuccess
+}
@@ -2,19 +2,32 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mdm-code/prg2p"
 )
 
 var (
-	rule string
-	all  bool
+	rule     string
+	all      bool
+	outDir   string
+	header   bool
+	filename bool
+	warn     bool
+	acronyms string
+	timeout  time.Duration
+	asJSON   bool
+	workers  int
+	ruleset  string
 )
 
 const (
@@ -22,17 +35,67 @@ const (
 	exitFailure
 )
 
+// commands maps subcommand names to functions running them with the
+// remaining command-line arguments and returning the exit code.
+var commands = map[string]func([]string) int{
+	"convert":  convertRules,
+	"coverage": coverage,
+	"diff":     diff,
+	"fmt":      format,
+	"learn":    learnRules,
+	"repl":     repl,
+	"reverse":  reverse,
+	"rulesets": rulesets,
+}
+
+// stdin is the file name that makes prg2p read from standard input.
+const stdin = "-"
+
+// outExt is the extension of result files written to the output directory.
+const outExt = ".g2p"
+
 const usage = `prg2p - grapheme-to-phoneme converter
 
-The prg2p utility reads space-delimited words sequentially from standard input,
-writing converted phonemic transcripts to standard output.
+The prg2p utility reads space-delimited words sequentially from each FILE, or
+standard input if no FILE is given, writing converted phonemic transcripts to
+standard output. A FILE of "-" stands for standard input.
+
+Usage:  prg2p [-h] [-r FILE] [-a BOOL] [-o DIR] [-H] [-f] [-w] [-A MODE] [-t DURATION]
+	[-j] [-p N] [-R NAME] [FILE ...]
+	prg2p COMMAND [ARGS ...]
 
-Usage:  prg2p [-h] [-r FILE] [-a BOOL] [FILE ...]
+Commands:
+	convert   convert rule files to JSON or YAML, see prg2p convert -h
+	coverage  report rules exercised by a corpus, see prg2p coverage -h
+	diff      compare transcripts of two rule files, see prg2p diff -h
+	fmt       format rule files, see prg2p fmt -h
+	learn     induce rules from a lexicon, see prg2p learn -h
+	repl      interactive rule debugger, see prg2p repl -h
+	reverse   spell phonemic transcripts, see prg2p reverse -h
+	rulesets  list rule sets shipped with prg2p, see prg2p rulesets -h
 
 Options:
-	-h, --help  show this help message and exit
-	-r, --rule  file with g2p rules (default: prg2p.Rules())
-	-a, --all   print all allowed conversions (default: false)
+	-h, --help        show this help message and exit
+	-r, --rules       file with g2p rules (default: prg2p.Rules())
+	-a, --all         print all allowed conversions (default: false)
+	-o, --output-dir  write results for each FILE to DIR/FILE.g2p, creating
+	                  DIR if needed; FILEs with the same base name are
+	                  rejected and results of failed FILEs are removed
+	-H, --header      print a header line before tab-separated results
+	-f, --filename    prefix each line with the name of the input FILE
+	-w, --warn        report rules overwritten by other rules on load
+	-A, --acronyms    transcribe acronyms as words (word), spell words in
+	                  upper case letter by letter except for acronyms read
+	                  as words like NATO (auto) or spell all words (spell)
+	                  (default: word)
+	-t, --timeout     give up transcribing after DURATION, such as 30s or
+	                  5m, and fail the remaining FILEs (default: no limit)
+	-j, --json        print one JSON object per word with its offsets, case
+	                  class and the name and version of the rules instead of
+	                  tab-separated lines
+	-p, --parallel    transcribe N words in parallel (default: 1)
+	-R, --ruleset     shipped rule set NAME listed by prg2p rulesets to use
+	                  instead of --rules (default: standard)
 
 Example:
 	echo ala ma kota | prg2p -r=rules.txt -a=false
@@ -44,60 +107,238 @@ Output:
 
 The program returns one word per line where each line contains tab-separated
 word, the number of variants and transcripts, which are separted with "|" in
-case of more than one variant.
+case of more than one variant. Errors are reported for each FILE separately
+and the program exits with a non-zero status if any of the FILEs failed.
 `
 
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
+
 	flag.StringVar(&rule, "r", "", "")
 	flag.StringVar(&rule, "rules", "", "")
 	flag.BoolVar(&all, "a", false, "")
 	flag.BoolVar(&all, "all", false, "")
+	flag.StringVar(&outDir, "o", "", "")
+	flag.StringVar(&outDir, "output-dir", "", "")
+	flag.BoolVar(&header, "H", false, "")
+	flag.BoolVar(&header, "header", false, "")
+	flag.BoolVar(&filename, "f", false, "")
+	flag.BoolVar(&filename, "filename", false, "")
+	flag.BoolVar(&warn, "w", false, "")
+	flag.BoolVar(&warn, "warn", false, "")
+	flag.StringVar(&acronyms, "A", "word", "")
+	flag.StringVar(&acronyms, "acronyms", "word", "")
+	flag.DurationVar(&timeout, "t", 0, "")
+	flag.DurationVar(&timeout, "timeout", 0, "")
+	flag.BoolVar(&asJSON, "j", false, "")
+	flag.BoolVar(&asJSON, "json", false, "")
+	flag.IntVar(&workers, "p", 1, "")
+	flag.IntVar(&workers, "parallel", 1, "")
+	flag.StringVar(&ruleset, "R", "", "")
+	flag.StringVar(&ruleset, "ruleset", "", "")
 	flag.Usage = func() { fmt.Print(usage) }
 	flag.Parse()
 
-	var (
-		f   io.Reader
-		err error
-	)
-	if rule == "" {
-		f = prg2p.Rules()
+	mode, err := prg2p.ParseAcronyms(acronyms)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		os.Exit(exitFailure)
+	}
+	if rule != "" && ruleset != "" {
+		fmt.Fprintf(os.Stderr, EOL("--rules and --ruleset cannot be used together"))
+		os.Exit(exitFailure)
+	}
+	var g2p *prg2p.G2P
+	if ruleset != "" {
+		g2p, err = prg2p.LoadNamed(ruleset, prg2p.WithAcronyms(mode))
 	} else {
-		f, err = os.Open(rule)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, EOL(err.Error()))
-			os.Exit(exitFailure)
-		}
+		g2p, err = load(rule, prg2p.WithAcronyms(mode))
 	}
-
-	g2p, err := prg2p.Load(f)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, EOL(err.Error()))
 		os.Exit(exitFailure)
 	}
+	if warn {
+		for _, o := range g2p.Overwrites() {
+			fmt.Fprintf(os.Stderr, EOL("warning: "+o.String()))
+		}
+	}
 
-	in := bufio.NewScanner(os.Stdin)
-	in.Split(bufio.ScanWords)
-	out := bufio.NewWriter(os.Stdout)
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{stdin}
+	}
 
-	for in.Scan() {
-		word := in.Text()
-		trans, err := g2p.Transcribe(word, all)
-		if err != nil {
+	var out *bufio.Writer
+	if outDir != "" {
+		if err := prepareOutDir(outDir, files); err != nil {
 			fmt.Fprintf(os.Stderr, EOL(err.Error()))
 			os.Exit(exitFailure)
 		}
-		line := FTrans(word, trans)
-		_, err = out.Write([]byte(EOL(line)))
+	}
+	if outDir == "" {
+		out = bufio.NewWriter(os.Stdout)
+		if header && !asJSON {
+			if _, err := out.WriteString(EOL(FHeader(filename))); err != nil {
+				fmt.Fprintf(os.Stderr, EOL(err.Error()))
+				os.Exit(exitFailure)
+			}
+		}
+	}
+
+	ctx, cancel := withTimeout(timeout)
+
+	code := exitSuccess
+	for _, name := range files {
+		var err error
+		if outDir == "" {
+			err = convert(ctx, g2p, name, out)
+		} else {
+			err = convertTo(ctx, g2p, name, outDir)
+		}
 		if err != nil {
+			fmt.Fprintf(os.Stderr, EOL(name+": "+err.Error()))
+			code = exitFailure
+		}
+	}
+	if out != nil {
+		if err := out.Flush(); err != nil {
 			fmt.Fprintf(os.Stderr, EOL(err.Error()))
 			os.Exit(exitFailure)
 		}
 	}
+	cancel()
+	os.Exit(code)
+}
+
+// withTimeout returns the context that is done after the duration d or never
+// if d is not positive.
+func withTimeout(d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), d)
+}
+
+// load returns G2P configured with opts with rules read from the file at
+// path or the default rules if path is empty.
+func load(path string, opts ...prg2p.Option) (*prg2p.G2P, error) {
+	if path == "" {
+		return prg2p.Load(prg2p.Rules(), opts...)
+	}
+	return prg2p.LoadFile(path, opts...)
+}
+
+// convert transcribes words from the file name and writes them to out. It
+// gives up once ctx is done.
+func convert(ctx context.Context, g2p *prg2p.G2P, name string, out io.Writer) error {
+	in, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	opts := prg2p.StreamOptions{All: all, Workers: workers, NewEncoder: encoder(name, g2p.Meta())}
+	return g2p.Stream(ctx, in, out, opts)
+}
+
+// jsonResult is the result printed with the --json flag. Rules identifies
+// the rule file that the result comes from.
+type jsonResult struct {
+	File string `json:"file,omitempty"`
+	prg2p.Result
+	Rules prg2p.Meta `json:"rules"`
+}
+
+// encoder returns the function creating the encoder of results of words
+// from the file name in the output format set with flags. JSON results carry
+// the metadata meta of the rules.
+func encoder(name string, meta prg2p.Meta) func(io.Writer) prg2p.Encoder {
+	return func(w io.Writer) prg2p.Encoder {
+		if asJSON {
+			enc := json.NewEncoder(w)
+			return prg2p.EncoderFunc(func(r prg2p.Result) error {
+				jr := jsonResult{Result: r, Rules: meta}
+				if filename {
+					jr.File = name
+				}
+				return enc.Encode(jr)
+			})
+		}
+		return prg2p.EncoderFunc(func(r prg2p.Result) error {
+			line := FTrans(r.Token, r.Variants)
+			if filename {
+				line = name + "\t" + line
+			}
+			_, err := io.WriteString(w, EOL(line))
+			return err
+		})
+	}
+}
+
+// outPath returns the path of the file in the directory dir that results of
+// words from the file name are written to.
+func outPath(dir, name string) string {
+	base := filepath.Base(name)
+	if name == stdin {
+		base = "stdin"
+	}
+	return filepath.Join(dir, base+outExt)
+}
+
+// prepareOutDir creates the directory dir unless it exists and fails if two
+// of the files would be written to the same result file.
+func prepareOutDir(dir string, files []string) error {
+	seen := make(map[string]string)
+	for _, name := range files {
+		path := outPath(dir, name)
+		if prev, ok := seen[path]; ok {
+			return fmt.Errorf("%s and %s would both be written to %s", prev, name, path)
+		}
+		seen[path] = name
+	}
+	return os.MkdirAll(dir, 0o755)
+}
+
+// convertTo transcribes words from the file name and writes them to a file
+// of the same name in the directory dir. The file is removed if the
+// conversion fails.
+func convertTo(ctx context.Context, g2p *prg2p.G2P, name, dir string) (err error) {
+	path := outPath(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(path)
+		}
+	}()
+	out := bufio.NewWriter(f)
+	if header && !asJSON {
+		if _, err := out.WriteString(EOL(FHeader(filename))); err != nil {
+			return err
+		}
+	}
+	if err := convert(ctx, g2p, name, out); err != nil {
+		return err
+	}
 	if err := out.Flush(); err != nil {
-		fmt.Fprintf(os.Stderr, EOL(err.Error()))
-		os.Exit(exitFailure)
+		return err
+	}
+	return f.Close()
+}
+
+// open returns the named file or standard input for the name "-".
+func open(name string) (io.ReadCloser, error) {
+	if name == stdin {
+		return io.NopCloser(os.Stdin), nil
 	}
-	os.Exit(exitSuccess)
+	return os.Open(name)
 }
 
 // EOL returns the string s with newline character at the end.
@@ -110,3 +351,13 @@ func FTrans(word string, trans []string) string {
 	joined := strings.Join(trans, "|")
 	return word + "\t" + strconv.Itoa(len(trans)) + "\t" + joined
 }
+
+// FHeader collates the header line naming the output columns. The file
+// column is included when fname is set.
+func FHeader(fname bool) string {
+	h := "word\tvariants\ttranscripts"
+	if fname {
+		h = "file\t" + h
+	}
+	return h
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mdm-code/prg2p"
+	"golang.org/x/term"
+)
+
+const replUsage = `prg2p repl - interactive grapheme-to-phoneme rule debugger
+
+Type words to see their transcripts or one of the commands listed below.
+Columns of rules added with :rule are separated with tabs like in rule files,
+since contexts may hold spaces; press Tab to type one.
+
+Usage:  prg2p repl [-h] [-r FILE]
+
+Options:
+	-h, --help   show this help message and exit
+	-r, --rules  file with g2p rules (default: prg2p.Rules())
+
+Commands:
+	:explain WORD  show which rule transcribed each part of WORD
+	:derive WORD   show phonological rules applied to variants of WORD
+	:rule RULE     add the tab-separated RULE to the live rules
+	:vars          list variables declared in the live rules
+	:reload        reload rules from FILE dropping the added rules
+	:diff          compare the live rules with FILE on disk
+	:help          show this help message
+	:quit          leave the REPL
+`
+
+const replPrompt = "prg2p> "
+
+// session holds the state of an interactive REPL session.
+type session struct {
+	path  string     // Path to the rule file, empty for prg2p.Rules().
+	src   string     // Rule file contents the session was loaded with.
+	added []string   // Lines added with :rule since the last reload.
+	g2p   *prg2p.G2P // Live transcriber.
+	out   io.Writer
+}
+
+// repl runs the interactive REPL subcommand with args.
+func repl(args []string) int {
+	var path string
+	fs := flag.NewFlagSet("repl", flag.ExitOnError)
+	fs.StringVar(&path, "r", "", "")
+	fs.StringVar(&path, "rules", "", "")
+	fs.Usage = func() { fmt.Print(replUsage) }
+	fs.Parse(args)
+
+	s := &session{path: path, out: os.Stdout}
+	if err := s.reload(); err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		in := bufio.NewScanner(os.Stdin)
+		for in.Scan() {
+			if !s.exec(in.Text()) {
+				break
+			}
+		}
+		return exitSuccess
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	defer term.Restore(fd, state)
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, replPrompt)
+	t.AutoCompleteCallback = insertTab
+	s.out = t
+	for {
+		l, err := t.ReadLine()
+		if err != nil {
+			break
+		}
+		if !s.exec(l) {
+			break
+		}
+	}
+	return exitSuccess
+}
+
+// exec executes a single line of REPL input. It returns false when the
+// session should end.
+func (s *session) exec(l string) bool {
+	l = strings.TrimSpace(l)
+	if l == "" {
+		return true
+	}
+	if !strings.HasPrefix(l, ":") {
+		for _, w := range strings.Fields(l) {
+			s.transcribe(w)
+		}
+		return true
+	}
+	cmd, arg := l, ""
+	if k := strings.IndexAny(l, " \t"); k >= 0 {
+		cmd, arg = l[:k], strings.TrimSpace(l[k:])
+	}
+	switch cmd {
+	case ":explain", ":e":
+		s.explain(arg)
+	case ":derive":
+		s.derive(arg)
+	case ":rule", ":r":
+		s.rule(arg)
+	case ":vars", ":v":
+		s.vars()
+	case ":reload":
+		if err := s.reload(); err != nil {
+			s.println(err.Error())
+		}
+	case ":diff", ":d":
+		s.diff()
+	case ":help", ":h":
+		s.println(strings.TrimSuffix(replUsage, "\n"))
+	case ":quit", ":q":
+		return false
+	default:
+		s.println("unknown command " + cmd)
+	}
+	return true
+}
+
+// transcribe prints all transcripts of the word w.
+func (s *session) transcribe(w string) {
+	trans, err := s.g2p.Transcribe(w, true)
+	if err != nil {
+		s.println(err.Error())
+		return
+	}
+	s.println(FTrans(w, trans))
+}
+
+// explain prints segments of the word w along with the rules that matched.
+func (s *session) explain(w string) {
+	segs, err := s.g2p.Align(w)
+	if err != nil {
+		s.println(err.Error())
+		return
+	}
+	for _, seg := range segs {
+		s.println(seg.Grapheme + "\t" + strings.Join(seg.Phonemes, "|") + "\t" + FSegment(seg))
+	}
+}
+
+// derive prints phonological rules applied to each variant of the word w.
+func (s *session) derive(w string) {
+	ds, err := s.g2p.Derive(w)
+	if err != nil {
+		s.println(err.Error())
+		return
+	}
+	for _, d := range ds {
+		s.println(d.Input)
+		for _, st := range d.Steps {
+			seg := prg2p.Segment{Rule: st.Rule, File: st.File, Line: st.Line}
+			s.println("\t-> " + st.After + "\t" + FSegment(seg))
+		}
+	}
+}
+
+// rule adds the rule r to the live rules. Columns must be separated with
+// tabs as in rule files, because contexts such as "(a, e)" or "SB SD" hold
+// spaces themselves.
+func (s *session) rule(r string) {
+	if !strings.Contains(r, "\t") {
+		s.println("expected tab-separated rule: BEFORE<TAB>CHARACTER<TAB>AFTER<TAB>PHONEME")
+		return
+	}
+	if err := s.g2p.Eval(r); err != nil {
+		s.println(err.Error())
+		return
+	}
+	s.added = append(s.added, r)
+}
+
+// vars prints variables declared in the live rules.
+func (s *session) vars() {
+	vars := s.g2p.Vars()
+	var names []string
+	for k := range vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		s.println(k + " = " + strings.Join(vars[k], ", "))
+	}
+}
+
+// reload reads the rule file anew and drops the rules added with :rule.
+func (s *session) reload() error {
+	src, err := readRules(s.path)
+	if err != nil {
+		return err
+	}
+	g2p, err := load(s.path)
+	if err != nil {
+		return err
+	}
+	s.src, s.g2p, s.added = src, g2p, nil
+	return nil
+}
+
+// diff prints rule lines of the live rules missing from the file on disk
+// with "+" and lines on disk missing from the live rules with "-".
+func (s *session) diff() {
+	disk, err := readRules(s.path)
+	if err != nil {
+		s.println(err.Error())
+		return
+	}
+	live := statements(s.src)
+	live = append(live, s.added...)
+	onDisk := statements(disk)
+	for _, l := range subtract(live, onDisk) {
+		s.println("+ " + l)
+	}
+	for _, l := range subtract(onDisk, live) {
+		s.println("- " + l)
+	}
+}
+
+// println writes the line l to the session output.
+func (s *session) println(l string) {
+	io.WriteString(s.out, EOL(l))
+}
+
+// readRules returns the contents of the rule file at path or the default
+// rules if path is empty.
+func readRules(path string) (string, error) {
+	if path == "" {
+		b, err := io.ReadAll(prg2p.Rules())
+		return string(b), err
+	}
+	b, err := os.ReadFile(path)
+	return string(b), err
+}
+
+// statements returns non-empty lines of the rule file src that are not
+// comments.
+func statements(src string) []string {
+	var out []string
+	for _, l := range strings.Split(src, "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// subtract returns lines from s1 that are not present in s2.
+func subtract(s1, s2 []string) []string {
+	ref := make(map[string]bool)
+	for _, l := range s2 {
+		ref[l] = true
+	}
+	var out []string
+	for _, l := range s1 {
+		if !ref[l] {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// insertTab lets the Tab key insert a tab separating columns of rules in
+// the line edited in the terminal.
+func insertTab(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	return line[:pos] + "\t" + line[pos:], pos + 1, true
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mdm-code/prg2p"
+)
+
+const reverseUsage = `prg2p reverse - phoneme-to-grapheme converter
+
+The prg2p reverse command reads phonemic transcripts, one per line with
+space-separated phonemes, sequentially from each FILE, or standard input if no
+FILE is given, writing spellings that the rules transcribe back to them to
+standard output. Spellings are ranked from the most likely one.
+
+Usage:  prg2p reverse [-h] [-r FILE] [-a BOOL] [FILE ...]
+
+Options:
+	-h, --help   show this help message and exit
+	-r, --rules  file with g2p rules (default: prg2p.Rules())
+	-a, --all    print all spellings (default: false)
+
+Example:
+	echo k o t a | prg2p reverse -a
+
+Output:
+	k o t a   2   kota|qota
+
+Transcripts that cannot be spelled are reported on standard error and the
+command exits with a non-zero status.
+`
+
+// reverse runs the phoneme-to-grapheme subcommand with args.
+func reverse(args []string) int {
+	var (
+		path string
+		all  bool
+	)
+	fs := flag.NewFlagSet("reverse", flag.ExitOnError)
+	fs.StringVar(&path, "r", "", "")
+	fs.StringVar(&path, "rules", "", "")
+	fs.BoolVar(&all, "a", false, "")
+	fs.BoolVar(&all, "all", false, "")
+	fs.Usage = func() { fmt.Print(reverseUsage) }
+	fs.Parse(args)
+
+	g2p, err := load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	p2g, err := prg2p.NewP2G(g2p)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{stdin}
+	}
+	out := bufio.NewWriter(os.Stdout)
+	code := exitSuccess
+	for _, name := range files {
+		if err := spell(p2g, name, all, out); err != nil {
+			fmt.Fprintf(os.Stderr, EOL(name+": "+err.Error()))
+			code = exitFailure
+		}
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	return code
+}
+
+// spell converts transcripts from the file name to spellings and writes them
+// to out. Transcripts that cannot be spelled are reported and skipped.
+func spell(p2g *prg2p.P2G, name string, all bool, out io.Writer) error {
+	in, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var failed int
+	s := bufio.NewScanner(in)
+	for s.Scan() {
+		trans := strings.Join(strings.Fields(s.Text()), " ")
+		if trans == "" {
+			continue
+		}
+		spellings, err := p2g.Transcribe(trans, all)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, EOL(name+": "+err.Error()))
+			failed++
+			continue
+		}
+		if _, err := io.WriteString(out, EOL(FTrans(trans, spellings))); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d transcript(s) could not be spelled", failed)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mdm-code/prg2p"
+)
+
+const rulesetsUsage = `prg2p rulesets - list shipped rule sets
+
+The prg2p rulesets command writes names and descriptions of rule sets shipped
+with prg2p to standard output, the default one first. Any of the names can be
+passed to prg2p with the --ruleset option.
+
+Usage:  prg2p rulesets [-h]
+
+Options:
+	-h, --help  show this help message and exit
+
+Example:
+	prg2p rulesets
+
+Output:
+	standard  Standard Polish pronunciation with optional variants
+	careful   Careful reading without optional assimilations and simplifications
+	regional  Kraków-Poznań voicing of final consonants before the next word
+`
+
+// rulesets runs the subcommand listing shipped rule sets with args.
+func rulesets(args []string) int {
+	fs := flag.NewFlagSet("rulesets", flag.ExitOnError)
+	fs.Usage = func() { fmt.Print(rulesetsUsage) }
+	fs.Parse(args)
+
+	out := bufio.NewWriter(os.Stdout)
+	for _, rs := range prg2p.RuleSets() {
+		fmt.Fprintf(out, "%-8s  %s\n", rs.Name, rs.Description)
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, EOL(err.Error()))
+		return exitFailure
+	}
+	return exitSuccess
+}
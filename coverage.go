@@ -0,0 +1,186 @@
+package prg2p
+
+import (
+	"sort"
+	"sync"
+)
+
+// maxExamples is the number of example words kept for each rule.
+const maxExamples = 3
+
+// RuleCoverage tells how many times a rule transcribed a part of a word and
+// lists up to three words it was used for.
+type RuleCoverage struct {
+	Rule     string   `json:"rule"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line"`
+	Hits     int      `json:"hits"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+// VarCoverage tells how many rules refer to a variable in their contexts,
+// how many of these rules fired and how many hits they got in total.
+type VarCoverage struct {
+	Name  string `json:"name"`
+	Rules int    `json:"rules"`
+	Fired int    `json:"fired"`
+	Hits  int    `json:"hits"`
+}
+
+// Coverage reports rules and variables exercised by the words transcribed
+// since coverage tracking was enabled. Rules are listed in the order they
+// were loaded and variables in alphabetical order. Failed words are the ones
+// that could not be transcribed.
+type Coverage struct {
+	Words  int            `json:"words"`
+	Failed int            `json:"failed"`
+	Rules  []RuleCoverage `json:"rules"`
+	Vars   []VarCoverage  `json:"vars"`
+}
+
+// Fired returns rules that transcribed at least one word ordered by the
+// number of hits from the most used one.
+func (c Coverage) Fired() []RuleCoverage {
+	var out []RuleCoverage
+	for _, r := range c.Rules {
+		if r.Hits > 0 {
+			out = append(out, r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Hits > out[j].Hits })
+	return out
+}
+
+// Unfired returns rules that did not transcribe any word in the order they
+// were loaded.
+func (c Coverage) Unfired() []RuleCoverage {
+	var out []RuleCoverage
+	for _, r := range c.Rules {
+		if r.Hits == 0 {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// tracker counts rule hits during transcription. It is safe for concurrent
+// use.
+type tracker struct {
+	mu       sync.Mutex
+	words    int
+	failed   int
+	hits     map[int]int      // Rule ID to the number of hits.
+	examples map[int][]string // Rule ID to the example words.
+}
+
+// newTracker returns an empty tracker.
+func newTracker() *tracker {
+	return &tracker{
+		hits:     make(map[int]int),
+		examples: make(map[int][]string),
+	}
+}
+
+// record counts rules of the trie nodes ts used to transcribe the word w.
+func (t *tracker) record(w string, ts []*trieNode) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.words++
+	for _, n := range ts {
+		if n.rule == nil {
+			continue
+		}
+		id := n.rule.id
+		t.hits[id]++
+		if ex := t.examples[id]; len(ex) < maxExamples && !contains(ex, w) {
+			t.examples[id] = append(ex, w)
+		}
+	}
+}
+
+// fail counts the word that could not be transcribed.
+func (t *tracker) fail() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.words++
+	t.failed++
+}
+
+// reset clears the counts.
+func (t *tracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.words, t.failed = 0, 0
+	t.hits = make(map[int]int)
+	t.examples = make(map[int][]string)
+}
+
+// report returns coverage of the rules in interp.
+func (t *tracker) report(interp *interpreter) Coverage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c := Coverage{Words: t.words, Failed: t.failed}
+	vars := make(map[string]*VarCoverage)
+	for k := range interp.rules {
+		r := &interp.rules[k]
+		rc := RuleCoverage{
+			Rule:     r.text,
+			File:     r.file,
+			Line:     r.line,
+			Hits:     t.hits[r.id],
+			Examples: append([]string{}, t.examples[r.id]...),
+		}
+		c.Rules = append(c.Rules, rc)
+		for _, name := range r.vars {
+			v, ok := vars[name]
+			if !ok {
+				v = &VarCoverage{Name: name}
+				vars[name] = v
+			}
+			v.Rules++
+			v.Hits += rc.Hits
+			if rc.Hits > 0 {
+				v.Fired++
+			}
+		}
+	}
+	for _, v := range vars {
+		c.Vars = append(c.Vars, *v)
+	}
+	sort.Slice(c.Vars, func(i, j int) bool { return c.Vars[i].Name < c.Vars[j].Name })
+	return c
+}
+
+// track records the trie nodes ts matched over the word w, or the failure
+// to transcribe it if err is not nil, when coverage tracking is enabled.
+func (g *G2P) track(w string, ts []*trieNode, err error) {
+	if g.cov == nil {
+		return
+	}
+	if err != nil {
+		g.cov.fail()
+		return
+	}
+	g.cov.record(w, ts)
+}
+
+// Coverage returns rule hit counts collected since the G2P was loaded with
+// WithCoverage or since the last ResetCoverage. Every word passed to
+// Transcribe, Derive or Align is counted. It returns an empty report with
+// all rules unfired if coverage tracking is disabled.
+func (g *G2P) Coverage() Coverage {
+	if g.interp == nil {
+		return Coverage{}
+	}
+	if g.cov == nil {
+		return newTracker().report(g.interp)
+	}
+	return g.cov.report(g.interp)
+}
+
+// ResetCoverage clears rule hit counts collected so far.
+func (g *G2P) ResetCoverage() {
+	if g.cov != nil {
+		g.cov.reset()
+	}
+}
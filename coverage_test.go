@@ -0,0 +1,140 @@
+package prg2p
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Rules for testing coverage with a rule that never fires.
+const coverageRules = `
+ALL = a, k, l, o, t
+EMPTY = *
+END = $
+SA = a, o
+EMPTY	a	EMPTY	a
+EMPTY	k	SA	k
+EMPTY	k	END	k, g
+EMPTY	l	EMPTY	l
+EMPTY	o	EMPTY	o
+EMPTY	t	EMPTY	t
+SA	t	END	t, d
+`
+
+// Test if Coverage counts rule hits and keeps example words.
+func TestCoverage(t *testing.T) {
+	g2p, err := Load(strings.NewReader(coverageRules), WithCoverage())
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	for _, w := range []string{"kota", "Ala", "kot", "ala", "tok", "x"} {
+		g2p.Transcribe(w, false)
+	}
+	c := g2p.Coverage()
+	if c.Words != 6 || c.Failed != 1 {
+		t.Errorf("have %d words and %d failed; want 6 and 1", c.Words, c.Failed)
+	}
+	hits := make(map[string]int)
+	for _, r := range c.Rules {
+		hits[r.Rule] = r.Hits
+	}
+	want := map[string]int{
+		"EMPTY	a	EMPTY	a":  5,
+		"EMPTY	k	SA	k":     2,
+		"EMPTY	k	END	k, g": 1,
+		"EMPTY	l	EMPTY	l":  2,
+		"EMPTY	o	EMPTY	o":  3,
+		"EMPTY	t	EMPTY	t":  2,
+		"SA	t	END	t, d":    1,
+	}
+	if ok := reflect.DeepEqual(hits, want); !ok {
+		t.Errorf("have %v; want: %v", hits, want)
+	}
+	if have := c.Rules[0].Examples; !reflect.DeepEqual(have, []string{"kota", "ala"}) {
+		t.Errorf("have %v; want: [kota ala]", have)
+	}
+	if have := c.Rules[0].Line; have != 6 {
+		t.Errorf("have line %d; want: 6", have)
+	}
+}
+
+// Test if words are counted each time they are transcribed even with the
+// cache enabled.
+func TestCoverageCache(t *testing.T) {
+	g2p, err := Load(strings.NewReader(coverageRules), WithCache(8), WithCoverage())
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	for _, w := range []string{"kot", "kot", "kot"} {
+		g2p.Transcribe(w, false)
+	}
+	c := g2p.Coverage()
+	if c.Words != 3 {
+		t.Errorf("have %d words; want 3", c.Words)
+	}
+	for _, r := range c.Fired() {
+		if r.Hits != 3 {
+			t.Errorf("have %d hits of %s; want 3", r.Hits, r.Rule)
+		}
+	}
+}
+
+// Test if Fired and Unfired split rules by hits and if variable usage is
+// summed over the rules referring to each variable.
+func TestCoverageFired(t *testing.T) {
+	g2p, err := Load(strings.NewReader(coverageRules), WithCoverage())
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	for _, w := range []string{"ala", "ala", "kot"} {
+		g2p.Transcribe(w, false)
+	}
+	c := g2p.Coverage()
+	var fired, unfired []string
+	for _, r := range c.Fired() {
+		fired = append(fired, r.Rule)
+	}
+	for _, r := range c.Unfired() {
+		unfired = append(unfired, r.Rule)
+	}
+	wantFired := []string{"EMPTY	a	EMPTY	a", "EMPTY	l	EMPTY	l", "EMPTY	k	SA	k", "EMPTY	o	EMPTY	o", "SA	t	END	t, d"}
+	wantUnfired := []string{"EMPTY	k	END	k, g", "EMPTY	t	EMPTY	t"}
+	if ok := reflect.DeepEqual(fired, wantFired); !ok {
+		t.Errorf("have %v; want: %v", fired, wantFired)
+	}
+	if ok := reflect.DeepEqual(unfired, wantUnfired); !ok {
+		t.Errorf("have %v; want: %v", unfired, wantUnfired)
+	}
+	wantVars := []VarCoverage{
+		{Name: "EMPTY", Rules: 6, Fired: 4, Hits: 8},
+		{Name: "END", Rules: 2, Fired: 1, Hits: 1},
+		{Name: "SA", Rules: 2, Fired: 2, Hits: 2},
+	}
+	if ok := reflect.DeepEqual(c.Vars, wantVars); !ok {
+		t.Errorf("have %v; want: %v", c.Vars, wantVars)
+	}
+}
+
+// Test if coverage is not tracked by default and if ResetCoverage clears
+// the counts.
+func TestCoverageDisabled(t *testing.T) {
+	g2p, err := Load(strings.NewReader(coverageRules))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	g2p.Transcribe("ala", false)
+	c := g2p.Coverage()
+	if c.Words != 0 || len(c.Fired()) != 0 || len(c.Rules) != 7 {
+		t.Errorf("have %+v; want no hits for 7 rules", c)
+	}
+
+	g2p, err = Load(strings.NewReader(coverageRules), WithCoverage())
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	g2p.Transcribe("ala", false)
+	g2p.ResetCoverage()
+	if c := g2p.Coverage(); c.Words != 0 || len(c.Fired()) != 0 {
+		t.Errorf("have %+v; want no hits after reset", c)
+	}
+}
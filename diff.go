@@ -0,0 +1,70 @@
+package prg2p
+
+// Change describes how transcription of a single word differs between two
+// rule sets. Old and New hold all variants offered by each rule set while
+// Added and Removed list variants present only on the new or the old side.
+// OldRules and NewRules hold segments of the word transcribed by rules that
+// are not used on the other side, that is, the rules responsible for the
+// change. OldErr and NewErr are set when one of the rule sets fails to
+// transcribe the word.
+type Change struct {
+	Word           string
+	Old, New       []string
+	Added, Removed []string
+	OldRules       []Segment
+	NewRules       []Segment
+	OldErr, NewErr error
+}
+
+// Diff transcribes words with both a and b and returns changes for words
+// whose sets of variants differ, so variants offered in a different order do
+// not count as a change. Changes are returned in the order of words and
+// repeated words are reported once.
+func Diff(a, b *G2P, words []string) []Change {
+	var out []Change
+	seen := make(map[string]bool)
+	for _, w := range words {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		c := Change{Word: w}
+		c.Old, c.OldErr = a.Transcribe(w, true)
+		c.New, c.NewErr = b.Transcribe(w, true)
+		if c.OldErr == nil && c.NewErr == nil && sameSet(c.Old, c.New) {
+			continue
+		}
+		if c.OldErr != nil && c.NewErr != nil {
+			continue
+		}
+		c.Added = rm(c.New, c.Old)
+		c.Removed = rm(c.Old, c.New)
+		oldSegs, _ := a.Align(w)
+		newSegs, _ := b.Align(w)
+		c.OldRules = rmSegments(oldSegs, newSegs)
+		c.NewRules = rmSegments(newSegs, oldSegs)
+		out = append(out, c)
+	}
+	return out
+}
+
+// rmSegments removes segments from the first slice if the same rule was used
+// to transcribe the same grapheme in the second slice.
+func rmSegments(s1, s2 []Segment) []Segment {
+	var out []Segment
+	ref := make(map[[2]string]bool)
+	for _, s := range s2 {
+		ref[[2]string{s.Grapheme, s.Rule}] = true
+	}
+	for _, s := range s1 {
+		if !ref[[2]string{s.Grapheme, s.Rule}] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// sameSet reports whether two slices hold the same strings in any order.
+func sameSet(s1, s2 []string) bool {
+	return len(rm(s1, s2)) == 0 && len(rm(s2, s1)) == 0
+}
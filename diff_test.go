@@ -0,0 +1,77 @@
+package prg2p
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test if Diff reports only words with changed transcripts along with the
+// rules responsible for the change.
+func TestDiff(t *testing.T) {
+	a, err := Load(rulesIO())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	var rules strings.Builder
+	rulesIO().WriteTo(&rules)
+	b, err := Load(strings.NewReader(rules.String() + "\nPUSTY\tk\tEND\tk, g"))
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	have := Diff(a, b, []string{"kot", "bok", "ala", "5"})
+	if len(have) != 1 {
+		t.Fatalf("have %d changes; want 1", len(have))
+	}
+	c := have[0]
+	if c.Word != "bok" {
+		t.Errorf("have %s; want bok", c.Word)
+	}
+	if ok := reflect.DeepEqual(c.Added, []string{"b o g"}); !ok {
+		t.Errorf("have %v; want: %v", c.Added, []string{"b o g"})
+	}
+	if len(c.Removed) != 0 {
+		t.Errorf("have %v; want no removed variants", c.Removed)
+	}
+	if len(c.OldRules) != 1 || c.OldRules[0].Rule != "PUSTY	k	-(b, ż)	k" {
+		t.Errorf("have %v; want the old rule for k", c.OldRules)
+	}
+	if len(c.NewRules) != 1 || c.NewRules[0].Rule != "PUSTY	k	END	k, g" {
+		t.Errorf("have %v; want the new rule for k", c.NewRules)
+	}
+}
+
+// Test if Diff ignores the order of variants and reports repeated words once.
+func TestDiffVariantOrder(t *testing.T) {
+	base := `
+ALL = a, b, k, o, $
+EMPTY = *
+END = $
+EMPTY	a	EMPTY	a
+EMPTY	o	EMPTY	o
+EMPTY	k	EMPTY	k
+EMPTY	b	EMPTY	b
+`
+	a, err := Load(strings.NewReader(base + "EMPTY\tb\tEND\tp, b\n"))
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	b, err := Load(strings.NewReader(base + "EMPTY\tb\tEND\tb, p\n"))
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	if have := Diff(a, b, []string{"bob", "kab"}); len(have) != 0 {
+		t.Errorf("have %v; want no changes", have)
+	}
+	c, err := Load(strings.NewReader(base + "EMPTY\tb\tEND\tp\n"))
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	have := Diff(a, c, []string{"bob", "kab", "bob"})
+	if len(have) != 2 || have[0].Word != "bob" || have[1].Word != "kab" {
+		t.Fatalf("have %v; want changes of bob and kab", have)
+	}
+	if ok := reflect.DeepEqual(have[0].Removed, []string{"b o b"}); !ok {
+		t.Errorf("have %v; want: [b o b]", have[0].Removed)
+	}
+}
@@ -32,5 +32,180 @@ Usage
 			fmt.Println(t)
 		}
 	}
+
+Rule files
+
+Columns of rules are separated with one or more tabs, and Format rewrites rule
+files in the canonical form with the columns aligned. Rule files can be split
+into modules with the #include directive followed by a quoted path resolved
+relative to the directory of the including file:
+
+	#include "preamble.txt"
+
+Left and right contexts of rules and values of variables can be written as
+set expressions combining variables and literal sets with intersection (&),
+union (+), difference (-) and complement (a leading -):
+
+	SONORANTS = SP & SD
+	SA+(j)	i	SP-(ł, l)	j
+
+Contexts spanning more than one symbol are written as whitespace-separated
+sequences of set expressions in reading order, so that the rule below applies
+to "ś" followed by "b" and a vowel:
+
+	EMPTY	ś	(b) SA	zi
+
+When two rules match the same source in the same context, the rule with the
+higher priority wins, then the one with the longer source, then the one with
+the more specific context listing fewer alternatives and, finally, the one
+that comes later in the file. Priority is 0 by default and it is set for the
+rules that follow the @priority directive in the same file:
+
+	@priority 10
+	EMPTY	b	END	p
+
+G2P.Overwrites reports every conflict resolved while the rules were loaded.
+
+Rules matching a word at the same position are scored in the same order, with
+the length of the matched context deciding between rules of equal priority and
+source length, so a narrow context always wins over a generic EMPTY rule. Pass
+WithMatcher(MatchFirst) to Load to use the first match found in the rule tree
+as earlier versions did.
+
+Characters of words can be replaced before they are transcribed with the MAP
+keyword, for example to spell out ligatures or drop apostrophes. Mappings are
+applied after normalization and case folding, longer characters first:
+
+	MAP ﬁ = fi
+	MAP ’ =
+
+Transcripts output by the rules can be further rewritten with ordered
+phonological rules declared in the [PHONOLOGY] section of the rule file in the
+A -> B / L _ R format, where "#" marks the word boundary and "0" deletes A.
+Rules are applied one after another to each variant and G2P.Derive reports
+the steps taken. The [RULES] header switches back to grapheme-to-phoneme rules.
+Names that are not variables stand for phonemes, except for names of two or
+more upper-case letters and digits, such as SDD, which are reported as
+undefined variables; such phonemes are written as literal sets like (OI).
+
+	[PHONOLOGY]
+	VOICED = b, d, g, z, rz, w
+	VOICELESS = p, t, k, s, sz, f
+	VOICED -> VOICELESS / VOICELESS _
+	e_ -> e n / _ (t, d)
+
+Use LoadFile to read rules from disk and LoadFS to read them from fs.FS, for
+example embed.FS, so that embedded and on-disk rule bundles behave the same.
+
+Rules returns the standard rule set, one of the rule sets shipped with the
+package. RuleSets lists all of them, for example the careful reading style
+without optional simplifications, and LoadNamed loads one by its name:
+
+	g2p, err := prg2p.LoadNamed("careful")
+
+Test cases can be declared next to the rules with the @test directive followed
+by a word and its transcripts separated with "|", and G2P.Check reports the
+ones that fail. Arbitrary metadata is set with the @meta directive:
+
+	@meta dialect standard
+	@test bok b o k | b o g
+
+Rule files describe themselves with the @name, @version, @author, @language,
+@phoneset and @requires directives, which G2P.Meta returns together with the
+@meta keys, so transcripts can be traced to the exact version of the rules.
+Only the metadata of the loaded file counts, not that of the files it
+includes. Rules requiring a later Version of the package with @requires,
+included ones too, fail to load:
+
+	@name standard
+	@version 1.0.0
+	@requires 1.0.0
+
+Rules can also be written in JSON or YAML as a RuleSet, which LoadFile and
+LoadFS recognize by the file extension and LoadRuleSet accepts directly.
+ReadRuleSet converts rule files in the line format to a RuleSet and
+RuleSet.WriteTo writes it back. Statements and comments keep their order, so
+variables redefined between rules mean the same after the conversion.
+
+Rule sets can also be built in code. Contexts are composed with Var, Set,
+Union, Intersect, Minus, Not and Seq, with Any standing for "*" that matches
+any character, and RuleSet.Compile validates the whole set before loading it:
+
+	rs := &prg2p.RuleSet{}
+	rs.Declare("SB", "p", "t", "k").
+		Add(prg2p.NewRule(prg2p.Any(), "b", prg2p.Union(prg2p.End(), prg2p.Var("SB")), "p"))
+	g2p, err := rs.Compile()
+
+Load, LoadFile and LoadFS take options that configure the transcriber once
+so that it can be shared by the whole application. Words are converted to NFC
+and lower-cased unless WithNormalization and WithCaseFolding say otherwise.
+WithUnknown decides what happens to characters that no rule transcribes,
+WithMaxVariants limits the number of variants, WithCache keeps transcripts of
+recent words, WithPhoneSet rejects rules outputting unknown phonemes,
+WithLexicon looks words up in a Lexicon before the rules and WithTrace writes
+the rules used for each word:
+
+	g2p, err := prg2p.Load(prg2p.Rules(),
+		prg2p.WithNormalization(prg2p.NFKC),
+		prg2p.WithUnknown(prg2p.UnknownSkip),
+		prg2p.WithCache(1024),
+	)
+
+G2P.TranscribeWord returns a Result that keeps the word as it was written, its
+normalized form, its case class, such as Upper for acronyms, and the
+variants. G2P.TranscribeText transcribes every whitespace-separated token of a
+text and reports their byte and rune offsets.
+
+Acronyms are transcribed as they are written by default. With
+WithAcronyms(AcronymsAuto) words in upper case are spelled letter by letter
+with Polish names of letters, so "PKP" becomes "p e k a p e", unless they are
+read as words like "NATO"; WithAcronymWords extends the list of such
+acronyms. AcronymsSpell spells every word.
+
+TranscribeContext, TranscribeWordContext, TranscribeTextContext and
+TranscribeBatch take a context.Context and give up with its error once it is
+cancelled or its deadline passes, which stops a word with a huge number of
+variants from running away:
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	results, err := g2p.TranscribeBatch(ctx, words, true)
+
+G2P.Stream reads words from io.Reader and writes their results to io.Writer
+with an Encoder, such as NewTSVEncoder or NewJSONEncoder, transcribing
+several words in parallel while keeping their order and holding only a few
+of them in memory at a time. The prg2p command is built on it:
+
+	opts := prg2p.StreamOptions{All: true, Workers: 4, NewEncoder: prg2p.NewJSONEncoder}
+	err := g2p.Stream(ctx, os.Stdin, os.Stdout, opts)
+
+Code that only transcribes words can depend on the Transcriber interface,
+which G2P, Lexicon and Chain implement. Chain tries transcribers in order, so
+a user dictionary read with ReadLexicon can take precedence over rules:
+
+	lex, err := prg2p.ReadLexicon(f)
+	var t prg2p.Transcriber = prg2p.Chain{lex, g2p}
+
+Transcription failures can be told apart with errors.Is and errors.As. Words
+with a character that no rule transcribes fail with *NoRuleError, which
+matches ErrNoRule and reports the character and its offset, words with
+nothing to transcribe fail with ErrEmptyInput and transcribers without rules
+fail with ErrNotLoaded:
+
+	var nr *prg2p.NoRuleError
+	if _, err := g2p.Transcribe(w, false); errors.As(err, &nr) {
+		fmt.Printf("no rule for %s at %d\n", nr.Char, nr.Offset)
+	}
+
+Load rules with WithCoverage to count how many times each rule is used while
+words are transcribed. G2P.Coverage reports the hits with example words,
+the rules that never fired and how the variables used in contexts fared.
+
+P2G works the other way round and offers spellings for phonemic transcripts.
+It is built from the same rules as G2P and only returns spellings that G2P
+transcribes back to the given phonemes:
+
+	p2g, err := prg2p.LoadP2G(prg2p.Rules())
+	spellings, err := p2g.Transcribe("p sz y", true)
 */
 package prg2p
@@ -0,0 +1,39 @@
+package prg2p
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoRule is reported when no rule transcribes a character of a word.
+	// Errors matching it with errors.Is are of type *NoRuleError.
+	ErrNoRule = errors.New("no rule")
+
+	// ErrEmptyInput is reported for words or transcripts with nothing to
+	// transcribe.
+	ErrEmptyInput = errors.New("empty input")
+
+	// ErrNotLoaded is reported by transcribers with no rules loaded.
+	ErrNotLoaded = errors.New("rules not loaded")
+)
+
+// NoRuleError reports the character of the word that no rule transcribes.
+// Word is the form of the word transcribed by rules after Unicode
+// normalization, case folding and character mapping, and Offset is the
+// position of Char in Word counted in runes.
+type NoRuleError struct {
+	Word   string
+	Offset int
+	Char   string
+}
+
+// Error returns the message naming the word and the offending character.
+func (e *NoRuleError) Error() string {
+	return fmt.Sprintf("failed to transcribe %s: no rule for %q at offset %d", e.Word, e.Char, e.Offset)
+}
+
+// Is reports whether target is ErrNoRule.
+func (e *NoRuleError) Is(target error) bool {
+	return target == ErrNoRule
+}
@@ -0,0 +1,68 @@
+package prg2p
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Test if transcription failures can be classified with errors.Is and
+// errors.As.
+func TestErrors(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	cases := []struct {
+		word string
+		want error
+	}{
+		{"", ErrEmptyInput},
+		{"’", ErrEmptyInput},
+		{"kot$", ErrNoRule},
+		{"Zażółć", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.word, func(t *testing.T) {
+			_, err := g2p.Transcribe(c.word, false)
+			if !errors.Is(err, c.want) {
+				t.Errorf("have %v; want: %v", err, c.want)
+			}
+		})
+	}
+	if _, err := g2p.Align(""); !errors.Is(err, ErrEmptyInput) {
+		t.Errorf("have %v; want: %v", err, ErrEmptyInput)
+	}
+	if _, err := new(G2P).Transcribe("kot", false); !errors.Is(err, ErrNotLoaded) {
+		t.Errorf("have %v; want: %v", err, ErrNotLoaded)
+	}
+	if _, err := NewP2G(new(G2P)); !errors.Is(err, ErrNotLoaded) {
+		t.Errorf("have %v; want: %v", err, ErrNotLoaded)
+	}
+}
+
+// Test if NoRuleError reports the offending character and its offset in the
+// normalized word.
+func TestNoRuleError(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	for _, f := range []func(string) error{
+		func(w string) error { _, err := g2p.Transcribe(w, true); return err },
+		func(w string) error { _, err := g2p.Derive(w); return err },
+		func(w string) error { _, err := g2p.Align(w); return err },
+	} {
+		err := f("Żółta§1")
+		var nr *NoRuleError
+		if !errors.As(err, &nr) {
+			t.Fatalf("have %v; want *NoRuleError", err)
+		}
+		if nr.Word != "żółta§1" || nr.Offset != 5 || nr.Char != "§" {
+			t.Errorf("have %+v; want: {Word:żółta§1 Offset:5 Char:§}", *nr)
+		}
+		if !strings.HasPrefix(err.Error(), "failed to transcribe żółta§1") {
+			t.Errorf("unexpected message %q", err)
+		}
+	}
+}
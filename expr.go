@@ -0,0 +1,395 @@
+package prg2p
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Set expressions describe contexts and variable values in terms of other
+// variables and inline literal sets. Operators in order of precedence:
+//
+//	-  complement
+//	&  intersection
+//	+  union
+//	-  difference
+//
+// Union and difference are left-associative and share precedence. A "-" with
+// no left operand is the complement: it subtracts the value that follows it
+// from ALL and binds tighter than any operator. Parentheses group
+// subexpressions unless they hold a comma-separated list of values or a single
+// value that is not a variable, in which case they form a literal set.
+//
+// Examples:
+// SA+(j)
+// SP-(ł, l)
+// -SB-END
+// SP & SD
+// (SA + SP) & -(ó)
+
+// tokenKind is the kind of a set expression token.
+type tokenKind int
+
+const (
+	tokName tokenKind = iota
+	tokLParen
+	tokRParen
+	tokComma
+	tokUnion
+	tokDiff
+	tokInter
+	tokEOF
+)
+
+// operators maps operator characters to their token kinds.
+var operators = map[rune]tokenKind{
+	'(': tokLParen,
+	')': tokRParen,
+	',': tokComma,
+	'+': tokUnion,
+	'-': tokDiff,
+	'&': tokInter,
+}
+
+// token is a single lexical unit of a set expression.
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int // Rune offset in the expression.
+}
+
+// exprParser is a recursive descent parser for set expressions. Variables
+// are looked up in the interpreter i. With bare set, names that are not
+// variables stand for themselves instead of causing an error unless they
+// look like variable names, see isVarName.
+type exprParser struct {
+	i    *interpreter
+	src  string
+	toks []token
+	cur  int
+	bare bool
+}
+
+// lex splits the expression s into tokens.
+func lex(s string) []token {
+	var out []token
+	rs := []rune(s)
+	for p := 0; p < len(rs); {
+		r := rs[p]
+		if r == ' ' || r == '\t' {
+			p++
+			continue
+		}
+		if k, ok := operators[r]; ok {
+			out = append(out, token{kind: k, val: string(r), pos: p})
+			p++
+			continue
+		}
+		start := p
+		for p < len(rs) {
+			if _, ok := operators[rs[p]]; ok || rs[p] == ' ' || rs[p] == '\t' {
+				break
+			}
+			p++
+		}
+		out = append(out, token{kind: tokName, val: string(rs[start:p]), pos: start})
+	}
+	return append(out, token{kind: tokEOF, pos: len(rs)})
+}
+
+// expr evaluates the set expression v to the list of values it denotes.
+func (i *interpreter) expr(v string) ([]string, error) {
+	p := &exprParser{i: i, src: v, toks: lex(v)}
+	out, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %q", t.val)
+	}
+	return out, nil
+}
+
+// sequence evaluates the whitespace-separated sequence of set expressions v.
+// It returns values of each expression in the order of the sequence. An
+// expression following another one cannot start with the complement
+// operator, which would be read as a difference, so it has to be enclosed in
+// parentheses.
+//
+// Examples:
+// SB SD
+// (s) SB
+// SP (-SD)
+func (i *interpreter) sequence(v string) ([][]string, error) {
+	p := &exprParser{i: i, src: v, toks: lex(v)}
+	return p.parseSequence()
+}
+
+// bareSequence evaluates the sequence of set expressions v like sequence
+// but names that are not variables are read as literal values unless they
+// look like variable names.
+func (i *interpreter) bareSequence(v string) ([][]string, error) {
+	p := &exprParser{i: i, src: v, toks: lex(v), bare: true}
+	return p.parseSequence()
+}
+
+// parseSequence parses whitespace-separated set expressions.
+func (p *exprParser) parseSequence() ([][]string, error) {
+	var out [][]string
+	for {
+		set, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, set)
+		switch t := p.peek(); t.kind {
+		case tokEOF:
+			return out, nil
+		case tokName, tokLParen:
+			continue
+		default:
+			return nil, p.errorf(t, "unexpected %q", t.val)
+		}
+	}
+}
+
+// peek returns the current token without consuming it.
+func (p *exprParser) peek() token {
+	return p.toks[p.cur]
+}
+
+// next consumes and returns the current token.
+func (p *exprParser) next() token {
+	t := p.toks[p.cur]
+	if t.kind != tokEOF {
+		p.cur++
+	}
+	return t
+}
+
+// errorf returns an error pointing at the position of the token t.
+func (p *exprParser) errorf(t token, format string, a ...any) error {
+	msg := fmt.Sprintf(format, a...)
+	return fmt.Errorf("%s at position %d in %s", msg, t.pos, p.src)
+}
+
+// parseExpr parses unions and differences of terms.
+func (p *exprParser) parseExpr() ([]string, error) {
+	out, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokUnion:
+			p.next()
+			term, err := p.parseTerm()
+			if err != nil {
+				return nil, err
+			}
+			out = union(out, term)
+		case tokDiff:
+			p.next()
+			term, err := p.parseTerm()
+			if err != nil {
+				return nil, err
+			}
+			out = rm(out, term)
+		default:
+			return out, nil
+		}
+	}
+}
+
+// parseTerm parses intersections of factors.
+func (p *exprParser) parseTerm() ([]string, error) {
+	out, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokInter {
+		p.next()
+		f, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		out = intersect(out, f)
+	}
+	return out, nil
+}
+
+// parseFactor parses a variable, a literal set, a parenthesized expression
+// or the complement of any of them.
+func (p *exprParser) parseFactor() ([]string, error) {
+	t := p.next()
+	switch t.kind {
+	case tokDiff:
+		all, ok := p.i.vars["ALL"]
+		if !ok {
+			return nil, p.errorf(t, "variable \"ALL\" not set")
+		}
+		f, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return rm(all, f), nil
+	case tokName:
+		return p.variable(t)
+	case tokLParen:
+		if p.isLiteral() {
+			return p.parseLiteral()
+		}
+		out, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokRParen {
+			return nil, p.errorf(r, "expected \")\"")
+		}
+		return out, nil
+	case tokEOF:
+		return nil, p.errorf(t, "unexpected end of expression")
+	default:
+		return nil, p.errorf(t, "unexpected %q", t.val)
+	}
+}
+
+// variable returns values of the variable named by the token t.
+func (p *exprParser) variable(t token) ([]string, error) {
+	vals, ok := p.i.vars[t.val]
+	if !ok && p.bare && !isVarName(t.val) {
+		return []string{t.val}, nil
+	}
+	if !ok {
+		return nil, p.errorf(t, "variable %q not found", t.val)
+	}
+	if strings.Join(vals, "") == "*" {
+		return nil, p.errorf(t, "variable %q matches any character", t.val)
+	}
+	return vals, nil
+}
+
+// isVarName reports whether the name looks like a variable: at least two
+// characters, the first an upper-case letter and the rest upper-case letters,
+// digits or underscores. Such names are never read as phonemes in
+// phonological rules so that a misspelled variable is reported; phonemes
+// written like this must be enclosed in a literal set, for example (OI).
+func isVarName(name string) bool {
+	rs := []rune(name)
+	if len(rs) < 2 || !unicode.IsUpper(rs[0]) {
+		return false
+	}
+	for _, r := range rs[1:] {
+		if !unicode.IsUpper(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// isLiteral reports whether the parenthesized group starting at the current
+// token is a literal set: a comma-separated list of values or a single value
+// that does not name a variable.
+func (p *exprParser) isLiteral() bool {
+	depth, names, ops := 0, 0, 0
+	for _, t := range p.toks[p.cur:] {
+		switch t.kind {
+		case tokLParen:
+			depth++
+		case tokRParen:
+			if depth == 0 {
+				if names != 1 || ops != 0 {
+					return false
+				}
+				_, isVar := p.i.vars[p.peek().val]
+				return !isVar
+			}
+			depth--
+		case tokComma:
+			if depth == 0 {
+				return true
+			}
+		case tokName:
+			names++
+		case tokEOF:
+			return ops == 0 && names <= 1
+		default:
+			ops++
+		}
+	}
+	return false
+}
+
+// parseLiteral parses a comma-separated list of values closed with ")".
+func (p *exprParser) parseLiteral() ([]string, error) {
+	var out []string
+	for {
+		t := p.next()
+		if t.kind != tokName {
+			return nil, p.errorf(t, "expected value")
+		}
+		out = append(out, t.val)
+		switch t := p.next(); t.kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return out, nil
+		default:
+			return nil, p.errorf(t, "expected \")\"")
+		}
+	}
+}
+
+// isExpr reports whether the value v of a variable assignment is a set
+// expression rather than a comma-separated list of values.
+func (i *interpreter) isExpr(v string) bool {
+	toks := lex(v)
+	depth := 0
+	for _, t := range toks {
+		switch t.kind {
+		case tokLParen:
+			depth++
+		case tokRParen:
+			depth--
+		case tokComma:
+			if depth == 0 {
+				return false
+			}
+		case tokUnion, tokDiff, tokInter:
+			return true
+		}
+	}
+	if toks[0].kind == tokLParen {
+		return true
+	}
+	_, isVar := i.vars[strings.TrimSpace(v)]
+	return isVar
+}
+
+// union returns items of the first slice followed by items of the second
+// slice not present in the first one.
+func union(s1, s2 []string) []string {
+	out := append([]string{}, s1...)
+	return append(out, rm(s2, s1)...)
+}
+
+// product returns concatenations of values picking one value from each set
+// in the order of sets.
+func product(sets [][]string) []string {
+	out := []string{""}
+	for _, set := range sets {
+		var next []string
+		for _, prefix := range out {
+			for _, v := range set {
+				next = append(next, prefix+v)
+			}
+		}
+		out = next
+	}
+	return out
+}
+
+// intersect returns items of the first slice present in the second slice.
+func intersect(s1, s2 []string) []string {
+	return rm(s1, rm(s1, s2))
+}
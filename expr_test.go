@@ -0,0 +1,112 @@
+package prg2p
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Fresh interpreter with variables for set expression tests.
+func exprInterpreter() *interpreter {
+	i := newInterpreter()
+	i.vars["ALL"] = []string{"a", "e", "j", "b", "d", "p", "t", "l", "ł", "$"}
+	i.vars["SA"] = []string{"a", "e"}
+	i.vars["SP"] = []string{"j", "b", "d", "p", "t", "l", "ł"}
+	i.vars["SD"] = []string{"b", "d", "l", "ł", "j"}
+	i.vars["SB"] = []string{"p", "t"}
+	i.vars["END"] = []string{"$"}
+	i.vars["PUSTY"] = []string{"*"}
+	return i
+}
+
+// Check if set expressions evaluate to the expected values.
+func TestExpr(t *testing.T) {
+	cases := []struct {
+		name, expr string
+		want       []string
+	}{
+		{"variable", "SA", []string{"a", "e"}},
+		{"literal", "(j)", []string{"j"}},
+		{"literal-list", "(p, t)", []string{"p", "t"}},
+		{"union", "SA+SB", []string{"a", "e", "p", "t"}},
+		{"union-literal", "SA+(j)", []string{"a", "e", "j"}},
+		{"union-dedup", "SA+(a, j)", []string{"a", "e", "j"}},
+		{"difference-literal", "SP-(ł, l)", []string{"j", "b", "d", "p", "t"}},
+		{"complement", "-SP-END", []string{"a", "e"}},
+		{"complement-literal", "-(a, e, $)", []string{"j", "b", "d", "p", "t", "l", "ł"}},
+		{"intersection", "SP & SD", []string{"j", "b", "d", "l", "ł"}},
+		{"precedence", "SB + SP & SD", []string{"p", "t", "j", "b", "d", "l", "ł"}},
+		{"precedence-diff", "SP - SD & (b, j)", []string{"d", "p", "t", "l", "ł"}},
+		{"left-assoc", "SP - SB + (p)", []string{"j", "b", "d", "l", "ł", "p"}},
+		{"grouping", "SP - (SB + (p))", []string{"j", "b", "d", "l", "ł"}},
+		{"grouping-var", "(SA)", []string{"a", "e"}},
+		{"grouping-inter", "(SA + SB) & -(a)", []string{"e", "p", "t"}},
+		{"nested-complement", "SP & (-SD)", []string{"p", "t"}},
+	}
+	i := exprInterpreter()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			have, err := i.expr(c.expr)
+			if err != nil {
+				t.Fatalf("failed to evaluate %s: %s", c.expr, err)
+			}
+			if ok := reflect.DeepEqual(have, c.want); !ok {
+				t.Errorf("have %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Check if malformed set expressions fail to evaluate.
+func TestExprError(t *testing.T) {
+	cases := []struct {
+		name, expr string
+	}{
+		{"empty", ""},
+		{"unknown-variable", "SA+XX"},
+		{"unclosed-group", "(SA + SB"},
+		{"unclosed-literal", "(a, b"},
+		{"unopened", "SA)"},
+		{"dangling-operator", "SA &"},
+		{"double-operator", "SA + & SB"},
+		{"empty-literal", "(a, )"},
+		{"wildcard", "PUSTY + SA"},
+	}
+	i := exprInterpreter()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := i.expr(c.expr); err == nil {
+				t.Errorf("expected %q to fail", c.expr)
+			}
+		})
+	}
+}
+
+// Check if variables can be defined with set expressions.
+func TestExprVariable(t *testing.T) {
+	i := exprInterpreter()
+	lines := []string{
+		"SONORANTS = SP & SD",
+		"VOICED = SONORANTS + (z)",
+		"ALIAS = SA",
+		"PLAIN = a, b",
+	}
+	for _, l := range lines {
+		if err := i.asVar(l); err != nil {
+			t.Fatalf("failed to evaluate %s: %s", l, err)
+		}
+	}
+	want := map[string][]string{
+		"SONORANTS": {"j", "b", "d", "l", "ł"},
+		"VOICED":    {"j", "b", "d", "l", "ł", "z"},
+		"ALIAS":     {"a", "e"},
+		"PLAIN":     {"a", "b"},
+	}
+	for k, v := range want {
+		if ok := reflect.DeepEqual(i.vars[k], v); !ok {
+			t.Errorf("%s: have %v; want: %v", k, i.vars[k], v)
+		}
+	}
+	if err := i.asVar("BAD = SP & XX"); err == nil {
+		t.Error("unknown variable should cause asVar to fail")
+	}
+}
@@ -0,0 +1,202 @@
+package prg2p
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// listSep matches commas with the spaces around them.
+var listSep = regexp.MustCompile(`\s*,\s*`)
+
+// Format returns the rule file src in the canonical form:
+//
+//   - rule columns are separated with tabs and aligned within blocks of
+//     consecutive rules,
+//   - list items are separated with a comma and a single space,
+//   - values of variables listed explicitly are sorted unless the variable
+//     is used in the phonology section, where the order of values matters,
+//   - phonological rules have single spaces around "->", "/" and "_",
+//   - runs of blank lines are collapsed and trailing blank lines removed.
+//
+// Comments, directives and sections are kept in place. Format checks the
+// syntax of each line but it does not evaluate rules, so it accepts rules
+// referring to variables declared in included files.
+func Format(src []byte) ([]byte, error) {
+	var lines []string
+	s := bufio.NewScanner(bytes.NewReader(src))
+	for s.Scan() {
+		lines = append(lines, strings.TrimSpace(s.Text()))
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	ordered := phonologyNames(lines)
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, '\t', tabwriter.StripEscape)
+	sect, wrote, blank := rulesSection, false, false
+	for n, l := range lines {
+		if l == "" {
+			blank = wrote
+			continue
+		}
+		if blank {
+			fmt.Fprintln(w)
+			blank = false
+		}
+		out, err := formatLine(l, sect, ordered)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		if strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]") {
+			sect = l
+		}
+		fmt.Fprintln(w, out)
+		wrote = true
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// formatLine returns the line l of the section sect in the canonical form.
+// Values of variables named in ordered are not sorted.
+func formatLine(l, sect string, ordered map[string]bool) (string, error) {
+	switch {
+	case strings.HasPrefix(l, "#"):
+		return escape(l), nil
+	case strings.HasPrefix(l, "@"):
+		return strings.Join(strings.Fields(l), " "), nil
+	case strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]"):
+		return l, nil
+	case isMap(l):
+		from, to, err := parseMap(l)
+		if err != nil {
+			return "", err
+		}
+		return escape(strings.TrimSpace(mapKeyword + " " + from + " = " + to)), nil
+	case sect == phonologySection && !strings.Contains(l, "="):
+		return formatPhonRule(l)
+	case strings.Contains(l, "="):
+		return formatVar(l, ordered)
+	}
+	cols := columns(l)
+	if len(cols) != 4 {
+		return "", fmt.Errorf("expected 4 columns in %s", l)
+	}
+	for k := range cols {
+		cols[k] = normalize(cols[k])
+	}
+	return strings.Join(cols[:3], "\t") + "\t" + escape(cols[3]), nil
+}
+
+// formatVar returns the variable assignment l in the canonical form.
+func formatVar(l string, ordered map[string]bool) (string, error) {
+	name, val, _ := strings.Cut(l, "=")
+	name, val = strings.TrimSpace(name), normalize(val)
+	if name == "" || strings.Contains(val, "=") {
+		return "", fmt.Errorf("expected single assignment in %s", l)
+	}
+	if val == "" {
+		return "", fmt.Errorf("no values to assign to variable in %s", l)
+	}
+	if !ordered[name] && !strings.ContainsAny(val, "()+-&*") {
+		vals := strings.Split(val, ", ")
+		sort.Strings(vals)
+		val = strings.Join(vals, ", ")
+	}
+	return escape(name + " = " + val), nil
+}
+
+// formatPhonRule returns the phonological rule l in the canonical form.
+func formatPhonRule(l string) (string, error) {
+	lhs, rhs, ok := strings.Cut(l, "->")
+	if !ok {
+		return "", fmt.Errorf("expected \"->\" in %s", l)
+	}
+	chg, env, hasEnv := strings.Cut(rhs, "/")
+	out := normalize(lhs) + " -> " + normalize(chg)
+	if hasEnv {
+		f := strings.Fields(env)
+		k := 0
+		for k < len(f) && f[k] != "_" {
+			k++
+		}
+		if k == len(f) {
+			return "", fmt.Errorf("expected \"_\" in the environment in %s", l)
+		}
+		out += " / " + strings.TrimSpace(normalize(strings.Join(f[:k], " "))+" _ "+normalize(strings.Join(f[k+1:], " ")))
+	}
+	return escape(out), nil
+}
+
+// phonologyNames returns names of variables declared or referred to in the
+// phonology sections of the rule file lines along with variables their values
+// are computed from. The order of their values matters since phonological
+// rules map values of sets index-wise.
+func phonologyNames(lines []string) map[string]bool {
+	out := make(map[string]bool)
+	mark := func(v string) {
+		for _, t := range lex(v) {
+			if t.kind == tokName {
+				out[t.val] = true
+			}
+		}
+	}
+	deps := make(map[string][]string)
+	sect := rulesSection
+	for _, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "#") || isMap(l):
+		case strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]"):
+			sect = l
+		case strings.Contains(l, "="):
+			name, val, _ := strings.Cut(l, "=")
+			name = strings.TrimSpace(name)
+			for _, t := range lex(val) {
+				if t.kind == tokName {
+					deps[name] = append(deps[name], t.val)
+				}
+			}
+			if sect == phonologySection {
+				out[name] = true
+				mark(val)
+			}
+		case sect == phonologySection:
+			mark(strings.NewReplacer("->", " ", "/", " ").Replace(l))
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, refs := range deps {
+			if !out[name] {
+				continue
+			}
+			for _, r := range refs {
+				if !out[r] {
+					out[r], changed = true, true
+				}
+			}
+		}
+	}
+	return out
+}
+
+// normalize collapses whitespace in the value v and separates list items
+// with a comma and a single space.
+func normalize(v string) string {
+	v = strings.Join(strings.Fields(v), " ")
+	return listSep.ReplaceAllString(v, ", ")
+}
+
+// escape protects the text s from being split into columns by tabwriter.
+func escape(s string) string {
+	esc := string([]byte{tabwriter.Escape})
+	return esc + s + esc
+}
@@ -0,0 +1,126 @@
+package prg2p
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// Test if Format rewrites rule files in the canonical form.
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		name, src, want string
+	}{
+		{
+			"columns",
+			"EMPTY\ta\tEMPTY\ta\n-(a,e,o)  \t u\t\tEMPTY\tl_ ,u\n",
+			"EMPTY\t\ta\tEMPTY\ta\n-(a, e, o)\tu\tEMPTY\tl_, u\n",
+		},
+		{
+			"sorted",
+			"SA = o,a ,  e\nSB = SA+(j)\nEMPTY = *\n",
+			"SA = a, e, o\nSB = SA+(j)\nEMPTY = *\n",
+		},
+		{
+			"blank",
+			"\n\n# Vowels\n\n\nSA = a\n\n",
+			"# Vowels\n\nSA = a\n",
+		},
+		{
+			"comments",
+			"#  Keep\tas  is\n@priority   10\n#include \"a.txt\"\n",
+			"#  Keep\tas  is\n@priority 10\n#include \"a.txt\"\n",
+		},
+		{
+			"phonology",
+			"V = d, b\n[PHONOLOGY]\nVL = t, p\nV->VL/  _ #\ne_ -> e  n /  _ (t,d)\n[RULES]\nX = b, a\n",
+			"V = d, b\n[PHONOLOGY]\nVL = t, p\nV -> VL / _ #\ne_ -> e n / _ (t, d)\n[RULES]\nX = a, b\n",
+		},
+		{
+			"map",
+			"MAP  ﬁ=fi\nMAP\t’ =  \n",
+			"MAP ﬁ = fi\nMAP ’ =\n",
+		},
+		{
+			"dependencies",
+			"SB = t, p\nVL = SB\n[PHONOLOGY]\nVL -> 0 / _ #\n",
+			"SB = t, p\nVL = SB\n[PHONOLOGY]\nVL -> 0 / _ #\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			have, err := Format([]byte(c.src))
+			if err != nil {
+				t.Fatalf("failed to format: %s", err)
+			}
+			if string(have) != c.want {
+				t.Errorf("have %q; want: %q", have, c.want)
+			}
+		})
+	}
+}
+
+// Test if Format errors out on malformed lines.
+func TestFormatFails(t *testing.T) {
+	cases := []string{
+		"EMPTY\ta\tEMPTY",
+		"EMPTY\ta\tEMPTY\ta\tb",
+		"SA = a = b",
+		"SA =  ",
+		" = a",
+		"[PHONOLOGY]\na b",
+		"[PHONOLOGY]\na -> b / c",
+	}
+	for _, src := range cases {
+		if _, err := Format([]byte(src)); err == nil {
+			t.Errorf("rules %q should cause an error", src)
+		}
+	}
+}
+
+// Test if the default rules formatted load and transcribe like the original
+// ones and if formatting is idempotent.
+func TestFormatRules(t *testing.T) {
+	src, err := io.ReadAll(Rules())
+	if err != nil {
+		t.Fatal("failed to read rules")
+	}
+	once, err := Format(src)
+	if err != nil {
+		t.Fatalf("failed to format: %s", err)
+	}
+	twice, err := Format(once)
+	if err != nil {
+		t.Fatalf("failed to format: %s", err)
+	}
+	if !bytes.Equal(once, twice) {
+		t.Error("formatting formatted rules should not change them")
+	}
+	a, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	b, err := Load(bytes.NewReader(once))
+	if err != nil {
+		t.Fatalf("failed to load formatted rules: %s", err)
+	}
+	words := strings.Fields("ala ma kota przy krzyk także chleb mówię zima wieś dżem dźwięk prośba tchórz")
+	if changes := Diff(a, b, words); len(changes) != 0 {
+		t.Errorf("have %d changed words; want none", len(changes))
+	}
+}
+
+// Test if rules with spaces around tabs and repeated tabs load.
+func TestAsRuleColumns(t *testing.T) {
+	i := newInterpreter()
+	i.vars["ALL"] = []string{"a", "b", "$"}
+	i.vars["EMPTY"] = []string{"*"}
+	if err := i.asRule("EMPTY \t\t a\t  EMPTY\t\t\ta, b "); err != nil {
+		t.Fatalf("failed to evaluate rule: %s", err)
+	}
+	r := i.rules[0]
+	if r.source != "a" || len(r.target) != 2 || r.target[1] != "b" {
+		t.Errorf("have %+v; want source a with targets a and b", r)
+	}
+}
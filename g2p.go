@@ -1,9 +1,16 @@
 package prg2p
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"io/fs"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // G2P transcriber class that takes a populated double trie tree
@@ -11,78 +18,507 @@ import (
 // interface that takes individual words and outputs their most
 // likely transcripts.
 type G2P struct {
-	tree *trieNode
+	tree       *trieNode
+	interp     *interpreter
+	overwrites []Overwrite
+	matcher    Matcher
+	cov        *tracker // Rule hit counts, nil unless WithCoverage is set.
+	fold       bool     // Whether words are lower-cased.
+	norm       Normalization
+	mapper     *strings.Replacer // Characters mapped with the MAP keyword.
+	unknown    Unknown
+	limit      int // Maximum number of variants, 0 if not limited.
+	cache      *cache
+	phones     map[string]bool // Phonemes rules may output, nil if any.
+	lexicon    *Lexicon        // Transcripts of exceptional words.
+	trace      io.Writer
+	traceMu    sync.Mutex
+	acronyms   Acronyms
+	extraWords []string        // Acronyms read as words set with WithAcronymWords.
+	readAsWord map[string]bool // All acronyms read as words prepared for lookup.
+}
+
+// Segment is a part of the word transcribed by a single rule. It holds the
+// source grapheme(s), the phonemic variants offered for them and the rule
+// that produced them along with the rule file name and line number. The file
+// name is empty for rules read from io.Reader and the line number is 0 for
+// rules that were not read from a file.
+type Segment struct {
+	Grapheme string
+	Phonemes []string
+	Rule     string
+	File     string
+	Line     int
 }
 
+// checkEvery is the number of variants enumerated between checks of
+// cancellation.
+const checkEvery = 1024
+
 // newG2P returns G2P object responsible for handling transcription.
 func newG2P(t *trieNode) *G2P {
 	g := G2P{
 		tree: t,
+		fold: true,
+		norm: NFC,
 	}
 	return &g
 }
 
-// Load returns a fully initialized G2P object with rules read from r.
-func Load(r io.Reader) (*G2P, error) {
+// Load returns a fully initialized G2P object with rules read from r and
+// configured with opts.
+func Load(r io.Reader, opts ...Option) (*G2P, error) {
 	interp := newInterpreter()
 	err := interp.scan(r)
 	if err != nil {
 		return nil, err
 	}
-	tree := newTree(interp)
+	return compile(interp, opts)
+}
+
+// LoadFile returns a fully initialized G2P object with rules read from the
+// file name. Files included with the #include directive are resolved relative
+// to the directory of the including file. Files with the .json, .yaml or .yml
+// extension are read as rule sets, see RuleSet.
+func LoadFile(name string, opts ...Option) (*G2P, error) {
+	interp := newInterpreter()
+	if err := interp.scanFile(name); err != nil {
+		return nil, err
+	}
+	return compile(interp, opts)
+}
+
+// LoadFS returns a fully initialized G2P object with rules read from the file
+// name in fsys. Files included with the #include directive are opened from
+// fsys as well.
+func LoadFS(fsys fs.FS, name string, opts ...Option) (*G2P, error) {
+	interp := newInterpreter()
+	interp.fsys = fsys
+	if err := interp.scanFile(name); err != nil {
+		return nil, err
+	}
+	return compile(interp, opts)
+}
+
+// compile returns G2P object with the tree built from rules in interp and
+// configured with opts. It fails if the rules require a later version of the
+// package or output phonemes outside of the phone set given with WithPhoneSet.
+func compile(interp *interpreter, opts []Option) (*G2P, error) {
+	tree, overwrites := buildTree(interp)
 	g2p := newG2P(tree)
+	g2p.interp, g2p.overwrites = interp, overwrites
+	for _, opt := range opts {
+		opt(g2p)
+	}
+	if err := checkRequires(interp.meta["requires"]); err != nil {
+		return nil, err
+	}
+	if err := g2p.checkPhones(interp.rules); err != nil {
+		return nil, err
+	}
+	if g2p.cov != nil || g2p.trace != nil {
+		// Words served from the cache would be neither counted nor traced.
+		g2p.cache = nil
+	}
+	g2p.buildMapper()
+	g2p.readAsWord = g2p.readAsWords()
+	if g2p.lexicon != nil {
+		g2p.lexicon = g2p.lexicon.withFold(g2p.prepare)
+	}
 	return g2p, nil
 }
 
+// checkPhones returns an error if any of the rules outputs a phoneme outside
+// of the phone set.
+func (g *G2P) checkPhones(rules []rule) error {
+	if g.phones == nil {
+		return nil
+	}
+	for _, r := range rules {
+		for _, t := range r.target {
+			for _, p := range strings.Fields(t) {
+				if !g.phones[p] {
+					return fmt.Errorf("phoneme %s outside of the phone set in rule %s", p, r.text)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// prepare returns the word w normalized, case-folded and with characters
+// mapped as configured.
+func (g *G2P) prepare(w string) string {
+	w = g.normalize(w)
+	if g.mapper != nil {
+		w = g.mapper.Replace(w)
+	}
+	return w
+}
+
+// normalize returns the word w in the configured Unicode normalization form
+// and case-folded. The dotted capital I is folded to the plain "i" rather
+// than "i" followed by the combining dot above.
+func (g *G2P) normalize(w string) string {
+	switch g.norm {
+	case NFC:
+		w = norm.NFC.String(w)
+	case NFD:
+		w = norm.NFD.String(w)
+	case NFKC:
+		w = norm.NFKC.String(w)
+	}
+	if g.fold {
+		w = strings.ReplaceAll(strings.ToLower(w), "i\u0307", "i")
+	}
+	return w
+}
+
+// buildMapper builds the replacer of characters mapped with the MAP keyword.
+// Characters to map are normalized like words and longer ones are replaced
+// first.
+func (g *G2P) buildMapper() {
+	g.mapper = nil
+	if g.interp == nil || len(g.interp.maps) == 0 {
+		return
+	}
+	var froms []string
+	for from := range g.interp.maps {
+		froms = append(froms, from)
+	}
+	sort.Slice(froms, func(i, j int) bool {
+		if len(froms[i]) != len(froms[j]) {
+			return len(froms[i]) > len(froms[j])
+		}
+		return froms[i] < froms[j]
+	})
+	var pairs []string
+	for _, from := range froms {
+		pairs = append(pairs, g.normalize(from), g.interp.maps[from])
+	}
+	g.mapper = strings.NewReplacer(pairs...)
+}
+
+// Overwrites returns conflicts between rules matching the same source in the
+// same context resolved while the rules were loaded. Conflicts are resolved in
+// favour of the rule with higher priority set with the @priority directive,
+// then the one with the longer source, then the one with the more specific
+// context listing fewer alternatives, and finally the one that comes later.
+func (g *G2P) Overwrites() []Overwrite {
+	return append([]Overwrite{}, g.overwrites...)
+}
+
+// Eval evaluates a single line in the rule file format, a variable
+// assignment or a rule, and adds it to the loaded rules. The tree is updated
+// in place so Eval must not be called concurrently with transcription.
+func (g *G2P) Eval(l string) error {
+	if g.tree == nil || g.interp == nil {
+		return ErrNotLoaded
+	}
+	n := len(g.interp.rules)
+	if err := g.interp.eval(strings.TrimSpace(l)); err != nil {
+		return err
+	}
+	if err := g.checkPhones(g.interp.rules[n:]); err != nil {
+		g.interp.rules = g.interp.rules[:n]
+		return err
+	}
+	g.cache.clear()
+	g.buildMapper()
+	for k := n; k < len(g.interp.rules); k++ {
+		g.overwrites = append(g.overwrites, g.tree.insert(&g.interp.rules[k])...)
+	}
+	return nil
+}
+
+// Vars returns the variables declared in the loaded rules with their values.
+func (g *G2P) Vars() map[string][]string {
+	out := make(map[string][]string)
+	if g.interp == nil {
+		return out
+	}
+	for k, v := range g.interp.vars {
+		out[k] = append([]string{}, v...)
+	}
+	return out
+}
+
 // Transcribe word from graphemic to phonemic transcription. Use n to specify
 // whether to return all possible transcriptions or just the first hit.
+// See TranscribeWord for the result keeping the original casing of the word.
 func (g *G2P) Transcribe(w string, all bool) ([]string, error) {
-	if g.tree == nil {
-		return []string{}, fmt.Errorf("trie node is nil")
+	return g.TranscribeContext(context.Background(), w, all)
+}
+
+// TranscribeContext transcribes the word w like Transcribe but gives up with
+// the error of ctx once it is cancelled or its deadline passes. Cancellation
+// is checked between segments of the word and while variants are
+// enumerated.
+func (g *G2P) TranscribeContext(ctx context.Context, w string, all bool) ([]string, error) {
+	res, err := g.TranscribeWordContext(ctx, w, all)
+	if err != nil {
+		return []string{}, err
+	}
+	return res.Variants, nil
+}
+
+// transcribe returns all transcripts of the prepared word w looking it up in
+// the lexicon first.
+func (g *G2P) transcribe(ctx context.Context, w string) ([]string, error) {
+	if ts, ok := g.lexicon.lookup(w); ok {
+		g.traceLexicon(w, ts)
+		return ts, nil
+	}
+	out, err := g.trackedVariants(ctx, w)
+	if err != nil {
+		return nil, err
 	}
+	if g.interp != nil && len(g.interp.phonology) > 0 {
+		var derived []string
+		for _, t := range out {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			derived = append(derived, g.interp.derive(t).Output)
+		}
+		out = unique(derived)
+	}
+	return out, nil
+}
+
+// Derive transcribes the word w and returns all variants with the steps of
+// ordered phonological rules applied to them. Variants are returned in the
+// order of Transcribe before phonological rules are applied.
+func (g *G2P) Derive(w string) ([]Derivation, error) {
+	w = g.prepare(w)
+	if ts, ok := g.lexicon.lookup(w); ok {
+		var ds []Derivation
+		for _, t := range ts {
+			ds = append(ds, Derivation{Input: t, Output: t})
+		}
+		return ds, nil
+	}
+	out, err := g.trackedVariants(context.Background(), w)
+	if err != nil {
+		return []Derivation{}, err
+	}
+	var ds []Derivation
+	for _, t := range out {
+		if g.interp == nil {
+			ds = append(ds, Derivation{Input: t, Output: t})
+			continue
+		}
+		ds = append(ds, g.interp.derive(t))
+	}
+	return ds, nil
+}
+
+// Align splits the word w into segments showing which rule transcribed
+// which part of the word. Segments of characters that no rule transcribes,
+// kept or skipped as set with WithUnknown, have no rule. Words found in the
+// lexicon are returned as a single segment with all their transcripts.
+func (g *G2P) Align(w string) ([]Segment, error) {
+	w = g.prepare(w)
+	if w == "" {
+		return []Segment{}, ErrEmptyInput
+	}
+	if ts, ok := g.lexicon.lookup(w); ok {
+		return []Segment{{Grapheme: w, Phonemes: append([]string{}, ts...)}}, nil
+	}
+	nodes, err := g.segments(context.Background(), w)
+	g.track(w, nodes, err)
+	if err != nil {
+		return []Segment{}, err
+	}
+	return align(w, nodes), nil
+}
+
+// align returns segments of the prepared word w transcribed by the trie
+// nodes.
+func align(w string, nodes []*trieNode) []Segment {
+	wRune := []rune(w)
+	var out []Segment
+	i := 0
+	for _, t := range nodes {
+		s := Segment{
+			Grapheme: string(wRune[i : i+t.nchars]),
+			Phonemes: t.output,
+		}
+		if t.rule != nil {
+			s.Rule, s.File, s.Line = t.rule.text, t.rule.file, t.rule.line
+		}
+		out = append(out, s)
+		i += t.nchars
+	}
+	return out
+}
+
+// variants returns all transcripts of the word w output by
+// grapheme-to-phoneme rules.
+func (g *G2P) variants(w string) ([]string, error) {
+	ctx := context.Background()
+	nodes, err := g.segments(ctx, g.prepare(w))
+	if err != nil {
+		return nil, err
+	}
+	return g.expand(ctx, nodes)
+}
+
+// trackedVariants returns variants of the prepared word w like variants and
+// records and traces the rules used.
+func (g *G2P) trackedVariants(ctx context.Context, w string) ([]string, error) {
+	nodes, err := g.segments(ctx, w)
+	g.track(w, nodes, err)
+	g.traceSegments(w, nodes, err)
+	if err != nil {
+		return nil, err
+	}
+	return g.expand(ctx, nodes)
+}
+
+// expand returns all transcripts offered by the trie nodes matched over a
+// word.
+func (g *G2P) expand(ctx context.Context, nodes []*trieNode) ([]string, error) {
 	var trans [][]string
-	w = strings.ToLower(w)
+	for _, t := range nodes {
+		if len(t.output) > 0 {
+			trans = append(trans, t.output)
+		}
+	}
+	return g.all(ctx, trans, 0)
+}
+
+// segments returns the trie nodes matched left to right over the prepared
+// word w. Characters that no rule transcribes get a node without a rule
+// unless the unknown character policy is UnknownFail.
+func (g *G2P) segments(ctx context.Context, w string) ([]*trieNode, error) {
+	if g.tree == nil {
+		return nil, ErrNotLoaded
+	}
+	var out []*trieNode
 	nchars := len([]rune(w))
 	i := 0
 	for i < nchars {
-		t := g.rightVars(w, i, i-1, g.tree)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var t *trieNode
+		if g.matcher == MatchFirst {
+			t = g.rightVars(w, i, i-1, g.tree)
+		} else {
+			t = g.match([]rune(w), i)
+		}
 		if t == nil {
-			return []string{}, fmt.Errorf("failed to transcribe %s", w)
+			switch g.unknown {
+			case UnknownSkip:
+				t = &trieNode{nchars: 1}
+			case UnknownKeep:
+				t = &trieNode{nchars: 1, output: []string{string([]rune(w)[i])}}
+			default:
+				return nil, &NoRuleError{Word: w, Offset: i, Char: string([]rune(w)[i])}
+			}
 		}
-		trans = append(trans, t.output)
+		out = append(out, t)
 		i += t.nchars
 	}
-	out, err := g.all(trans, 0)
-	if err != nil {
-		return []string{}, err
-	}
-	if all == true {
-		return out, nil
-	}
-	return out[:1], nil
+	return out, nil
 }
 
-// All grabs all possible transcription variants.
-func (g *G2P) all(trans [][]string, i int) ([]string, error) {
+// All grabs all possible transcription variants. Cancellation of ctx is
+// checked every checkEvery variants.
+func (g *G2P) all(ctx context.Context, trans [][]string, i int) ([]string, error) {
 	if len(trans) == 0 {
-		return []string{}, fmt.Errorf("no transcription variants offered")
+		return []string{}, fmt.Errorf("no transcription variants offered: %w", ErrEmptyInput)
 	}
 	if i == len(trans)-1 {
-		return trans[len(trans)-1], nil
+		last := trans[len(trans)-1]
+		if g.limit > 0 && len(last) > g.limit {
+			last = last[:g.limit]
+		}
+		return last, nil
 	}
-	rest, err := g.all(trans, i+1)
+	rest, err := g.all(ctx, trans, i+1)
 	if err != nil {
 		return []string{}, err
 	}
 	var result []string
 	for _, i := range trans[i] {
 		for _, j := range rest {
+			if g.limit > 0 && len(result) == g.limit {
+				return result, nil
+			}
+			if len(result)%checkEvery == 0 {
+				if err := ctx.Err(); err != nil {
+					return []string{}, err
+				}
+			}
 			result = append(result, i+" "+j)
 		}
 	}
 	return result, nil
 }
 
+// match returns the most specific trie node matching the word w at the
+// position i considering all paths through its right and left context.
+func (g *G2P) match(w []rune, i int) *trieNode {
+	var (
+		best    *trieNode
+		bestCtx int
+	)
+	visit := func(t *trieNode, ctx int) {
+		if t.nchars == 0 || t.rule == nil {
+			return
+		}
+		if best == nil || beats(t, ctx, best, bestCtx) {
+			best, bestCtx = t, ctx
+		}
+	}
+	var walkLeft func(t *trieNode, back, nleft, nright int)
+	walkLeft = func(t *trieNode, back, nleft, nright int) {
+		visit(t, nright-t.nchars+nleft)
+		if back >= 0 {
+			if next, ok := t.left[string(w[back])]; ok {
+				walkLeft(next, back-1, nleft+1, nright)
+			}
+		}
+		if back == -1 {
+			if next, ok := t.left["$"]; ok {
+				walkLeft(next, -2, nleft+1, nright)
+			}
+		}
+	}
+	var walkRight func(t *trieNode, front, nright int)
+	walkRight = func(t *trieNode, front, nright int) {
+		walkLeft(t, i-1, 0, nright)
+		if front < len(w) {
+			if next, ok := t.right[string(w[front])]; ok {
+				walkRight(next, front+1, nright+1)
+			}
+		}
+		if front == len(w) {
+			if next, ok := t.right["$"]; ok {
+				walkLeft(next, i-1, 0, nright+1)
+			}
+		}
+	}
+	walkRight(g.tree, i, 0)
+	return best
+}
+
+// beats reports whether the trie node a with ctx characters of matched
+// context is more specific than the node b with bctx characters of context.
+func beats(a *trieNode, ctx int, b *trieNode, bctx int) bool {
+	switch {
+	case a.rule.priority != b.rule.priority:
+		return a.rule.priority > b.rule.priority
+	case a.nchars != b.nchars:
+		return a.nchars > b.nchars
+	case ctx != bctx:
+		return ctx > bctx
+	}
+	return a.rule.specificity() < b.rule.specificity()
+}
+
 // RightVars traverses the right-hand side of the complete double trie.
 func (g *G2P) rightVars(w string, frontIdx, backIdx int, trie *trieNode) *trieNode {
 	wRune := []rune(w)
@@ -116,11 +552,12 @@ func (g *G2P) rightVars(w string, frontIdx, backIdx int, trie *trieNode) *trieNo
 // LeftVars traverses left-hand side part of the complete double trie.
 func (g *G2P) leftVars(w string, backIdx int, trie *trieNode) *trieNode {
 	wRune := []rune(w)
-	curChar := string(wRune[len(wRune)-2-backIdx])
-
+	var curChar string
+	if backIdx >= 0 {
+		curChar = string(wRune[backIdx])
+	}
 	if t, ok := trie.left[curChar]; backIdx >= 0 && ok {
-		backIdx--
-		t := g.leftVars(w, backIdx, t)
+		t := g.leftVars(w, backIdx-1, t)
 		if t != nil {
 			return t
 		}
@@ -135,3 +572,58 @@ func (g *G2P) leftVars(w string, backIdx int, trie *trieNode) *trieNode {
 	}
 	return nil
 }
+
+// unique returns items of the slice s without repetitions keeping the order
+// of their first occurrence.
+func unique(s []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, e := range s {
+		if !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// traceSegments writes the trie nodes matched over the prepared word w, or
+// the error if it could not be transcribed, to the trace writer.
+func (g *G2P) traceSegments(w string, nodes []*trieNode, err error) {
+	if g.trace == nil {
+		return
+	}
+	var b strings.Builder
+	if err != nil {
+		b.WriteString(w + "\t" + err.Error() + "\n")
+	}
+	for _, s := range align(w, nodes) {
+		loc := "-"
+		switch {
+		case s.Rule == "":
+		case s.File != "":
+			loc = s.File + ":" + strconv.Itoa(s.Line)
+		default:
+			loc = "line " + strconv.Itoa(s.Line)
+		}
+		b.WriteString(w + "\t" + s.Grapheme + "\t" + strings.Join(s.Phonemes, "|") + "\t" + loc + "\n")
+	}
+	g.writeTrace(b.String())
+}
+
+// traceLexicon writes the transcripts ts of the word w found in the lexicon
+// to the trace writer.
+func (g *G2P) traceLexicon(w string, ts []string) {
+	if g.trace == nil {
+		return
+	}
+	g.writeTrace(w + "\t" + w + "\t" + strings.Join(ts, "|") + "\tlexicon\n")
+}
+
+// writeTrace writes s to the trace writer guarding it against concurrent
+// writes.
+func (g *G2P) writeTrace(s string) {
+	g.traceMu.Lock()
+	defer g.traceMu.Unlock()
+	io.WriteString(g.trace, s)
+}
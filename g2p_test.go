@@ -1,9 +1,14 @@
 package prg2p
 
 import (
+	"context"
+	"errors"
 	"io"
 	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 // Fresh instance of populated *TrieNode for unit testing.
@@ -57,7 +62,7 @@ func TestTranscribe(t *testing.T) {
 		{"kota-f", "Kota", false, []string{"k o t a"}},
 		{"kota-f-capital", "Kota", false, []string{"k o t a"}},
 		{"chcę-t-capital", "Chcę", true, []string{"h c e", "h c e_"}},
-		{"mówię-t", "mówię", true, []string{"m u w i e", "m u w i e_"}},
+		{"mówię-t", "mówię", true, []string{"m u w j e", "m u w j e_"}},
 	}
 	g2p, err := Load(rulesIO())
 	if err != nil {
@@ -101,3 +106,405 @@ func TestTranscribeFails(t *testing.T) {
 		})
 	}
 }
+
+// Test if G2P.Align() reports the rules matched for each part of the word.
+func TestAlign(t *testing.T) {
+	g2p, err := Load(rulesIO())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	have, err := g2p.Align("Szok")
+	if err != nil {
+		t.Fatalf("failed to align: %s", err)
+	}
+	want := []struct {
+		grapheme, rule string
+		phonemes       []string
+	}{
+		{"sz", "PUSTY	sz	PUSTY	sz", []string{"sz"}},
+		{"o", "PUSTY	o	PUSTY	o", []string{"o"}},
+		{"k", "PUSTY	k	-(b, ż)	k", []string{"k"}},
+	}
+	if len(have) != len(want) {
+		t.Fatalf("have %d segments; want %d", len(have), len(want))
+	}
+	for i, w := range want {
+		s := have[i]
+		if s.Grapheme != w.grapheme || s.Rule != w.rule || s.Line == 0 {
+			t.Errorf("have %+v; want %+v", s, w)
+		}
+		if ok := reflect.DeepEqual(s.Phonemes, w.phonemes); !ok {
+			t.Errorf("have %v; want: %v", s.Phonemes, w.phonemes)
+		}
+	}
+}
+
+// Test if G2P.Eval() adds variables and rules to the loaded transcriber.
+func TestG2PEval(t *testing.T) {
+	g2p, err := Load(rulesIO())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	if _, err := g2p.Transcribe("xylofon", false); err != nil {
+		t.Fatal("failed to transcribe xylofon")
+	}
+	lines := []string{
+		"GR = ks",
+		"PUSTY	x	PUSTY	g z",
+	}
+	for _, l := range lines {
+		if err := g2p.Eval(l); err != nil {
+			t.Fatalf("failed to evaluate %s: %s", l, err)
+		}
+	}
+	have, err := g2p.Transcribe("xylofon", false)
+	if err != nil {
+		t.Fatal("failed to transcribe xylofon")
+	}
+	want := []string{"g z y l o f o n"}
+	if ok := reflect.DeepEqual(have, want); !ok {
+		t.Errorf("have %v; want: %v", have, want)
+	}
+	if v := g2p.Vars()["GR"]; !reflect.DeepEqual(v, []string{"ks"}) {
+		t.Errorf("have %v; want: %v", v, []string{"ks"})
+	}
+	if err := g2p.Eval("PUSTY	x	PUSTY"); err == nil {
+		t.Error("malformed rule should cause Eval to fail")
+	}
+}
+
+// Test if LoadFS reads rules split across files in the file system.
+func TestLoadFS(t *testing.T) {
+	var rules strings.Builder
+	rulesIO().WriteTo(&rules)
+	fsys := fstest.MapFS{
+		"rules.txt": {Data: []byte(rules.String())},
+		"main.txt":  {Data: []byte("#include \"rules.txt\"\n")},
+	}
+	g2p, err := LoadFS(fsys, "main.txt")
+	if err != nil {
+		t.Fatalf("failed to load G2P rules transcriber: %s", err)
+	}
+	have, err := g2p.Align("kot")
+	if err != nil {
+		t.Fatalf("failed to align: %s", err)
+	}
+	if have[0].File != "rules.txt" {
+		t.Errorf("have %s; want rules.txt", have[0].File)
+	}
+	if _, err := LoadFS(fsys, "nowhere.txt"); err == nil {
+		t.Error("missing file should cause LoadFS to fail")
+	}
+}
+
+// Test if a left context of one symbol is read from the character right before
+// the source. Earlier versions read left contexts mirrored from the end of the
+// word, so the rule below fired for "cba" instead of "abc".
+func TestTranscribeLeftContext(t *testing.T) {
+	const rules = `
+ALL = a, b, c, $
+EMPTY = *
+EMPTY	a	EMPTY	a
+EMPTY	b	EMPTY	b
+EMPTY	c	EMPTY	c
+(a)	b	EMPTY	x
+`
+	cases := []struct {
+		word string
+		want []string
+	}{
+		{"abc", []string{"a x c"}},
+		{"cba", []string{"c b a"}},
+		{"ab", []string{"a x"}},
+		{"ba", []string{"b a"}},
+		{"cbab", []string{"c b a x"}},
+	}
+	matchers := []struct {
+		name string
+		m    Matcher
+	}{
+		{"specific", MatchSpecific},
+		{"first", MatchFirst},
+	}
+	for _, m := range matchers {
+		g2p, err := Load(strings.NewReader(rules), WithMatcher(m.m))
+		if err != nil {
+			t.Fatalf("failed to create G2P transcriber: %s", err)
+		}
+		for _, c := range cases {
+			t.Run(m.name+"/"+c.word, func(t *testing.T) {
+				have, err := g2p.Transcribe(c.word, true)
+				if err != nil {
+					t.Fatalf("failed to transcribe: %s", c.word)
+				}
+				if ok := reflect.DeepEqual(have, c.want); !ok {
+					t.Errorf("have %v; want: %v", have, c.want)
+				}
+			})
+		}
+	}
+}
+
+// Test if rules with contexts of more than one symbol handle assimilation in
+// consonant clusters.
+func TestTranscribeClusters(t *testing.T) {
+	rules := strings.NewReader(`
+ALL = a, b, e, k, o, p, r, s, ś, t, ż, ł, rz, $
+PUSTY = *
+SA = a, e, o
+SB = k, p, s, ś, t
+END = $
+PUSTY	a	PUSTY	a
+PUSTY	b	PUSTY	b
+PUSTY	e	PUSTY	e
+PUSTY	o	PUSTY	o
+PUSTY	p	PUSTY	p
+PUSTY	r	PUSTY	r
+PUSTY	s	PUSTY	s
+PUSTY	t	PUSTY	t
+PUSTY	ł	PUSTY	l_
+PUSTY	ż	PUSTY	rz
+PUSTY	k	PUSTY	k
+PUSTY	ś	PUSTY	si
+PUSTY	rz	PUSTY	rz
+PUSTY	ś	(b) SA	zi
+SA	k	(ż) SA	g
+(s) (t)	rz	PUSTY	sz
+-SB SB	rz	PUSTY	sz
+`)
+	cases := []struct {
+		word string
+		want []string
+	}{
+		{"prośba", []string{"p r o zi b a"}},
+		{"prośb", []string{"p r o si b"}},
+		{"także", []string{"t a g rz e"}},
+		{"tkże", []string{"t k rz e"}},
+		{"strzał", []string{"s t sz a l_"}},
+		{"otrzeć", nil},
+		{"otrze", []string{"o t sz e"}},
+		{"ttrze", []string{"t t rz e"}},
+	}
+	g2p, err := Load(rules)
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	for _, c := range cases {
+		t.Run(c.word, func(t *testing.T) {
+			have, err := g2p.Transcribe(c.word, true)
+			if c.want == nil {
+				if err == nil {
+					t.Errorf("word %s was expected to cause error", c.word)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to transcribe: %s", c.word)
+			}
+			if ok := reflect.DeepEqual(have, c.want); !ok {
+				t.Errorf("have %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Test the specific and the legacy first-match strategies on words where the
+// shape of the tree makes the first match differ from the most specific one.
+func TestMatcher(t *testing.T) {
+	cases := []struct {
+		name, word      string
+		specific, first []string
+	}{
+		{"przy", "przy", []string{"p sz y"}, []string{"p sz y", "p rz y"}},
+		{"także", "także", []string{"t a k sz e", "t a g sz e"}, []string{"t a k sz e", "t a k rz e", "t a g sz e", "t a g rz e"}},
+		{"krzyk", "krzyk", []string{"k sz y k"}, []string{"k sz y k"}},
+		{"mówię", "mówię", []string{"m u w j e", "m u w j e_"}, []string{"m u w j e", "m u w j e_"}},
+		{"chleb", "chleb", []string{"h l e p", "h l e b"}, []string{"h l e p", "h l e b"}},
+	}
+	specific, err := Load(rulesIO())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	first, err := Load(rulesIO(), WithMatcher(MatchFirst))
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			have, err := specific.Transcribe(c.word, true)
+			if err != nil {
+				t.Fatalf("failed to transcribe: %s", c.word)
+			}
+			if ok := reflect.DeepEqual(have, c.specific); !ok {
+				t.Errorf("specific: have %v; want: %v", have, c.specific)
+			}
+			have, err = first.Transcribe(c.word, true)
+			if err != nil {
+				t.Fatalf("failed to transcribe: %s", c.word)
+			}
+			if ok := reflect.DeepEqual(have, c.first); !ok {
+				t.Errorf("first: have %v; want: %v", have, c.first)
+			}
+		})
+	}
+}
+
+// Test if the specific matcher prefers a rule transcribing more characters
+// over a rule with a longer context, which the first match may pick instead.
+func TestMatcherContext(t *testing.T) {
+	rules := `
+ALL = a, b, k, o
+PUSTY = *
+PUSTY	a	PUSTY	a
+PUSTY	b	PUSTY	b
+PUSTY	k	PUSTY	k
+PUSTY	o	PUSTY	o
+PUSTY	ko	PUSTY	k o
+(a)	k	PUSTY	g
+(a) (b)	k	(o)	g
+`
+	cases := []struct {
+		word            string
+		specific, first []string
+	}{
+		{"ako", []string{"a k o"}, []string{"a k o"}},
+		{"abko", []string{"a b k o"}, []string{"a b g o"}},
+		{"ak", []string{"a g"}, []string{"a g"}},
+		{"abk", []string{"a b k"}, []string{"a b k"}},
+	}
+	specific, err := Load(strings.NewReader(rules))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	first, err := Load(strings.NewReader(rules), WithMatcher(MatchFirst))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	for _, c := range cases {
+		t.Run(c.word, func(t *testing.T) {
+			have, _ := specific.Transcribe(c.word, true)
+			if ok := reflect.DeepEqual(have, c.specific); !ok {
+				t.Errorf("specific: have %v; want: %v", have, c.specific)
+			}
+			have, _ = first.Transcribe(c.word, true)
+			if ok := reflect.DeepEqual(have, c.first); !ok {
+				t.Errorf("first: have %v; want: %v", have, c.first)
+			}
+		})
+	}
+}
+
+// Test if words with decomposed diacritics, dotted capital I, typographic
+// apostrophes and ligatures are transcribed like their plain spelling.
+func TestTranscribeNormalized(t *testing.T) {
+	cases := []struct {
+		have, want string
+	}{
+		{"ze\u0328by", "zęby"},
+		{"z\u0307o\u0301\u0142c\u0301", "żółć"},
+		{"ge\u0328s\u0301la\u0328", "gęślą"},
+		{"ZAZ\u0307O\u0301\u0141C\u0301", "zażółć"},
+		{"\u0130G\u0141A", "igła"},
+		{"Kennedy\u2019ego", "kennedyego"},
+		{"Kennedy\u02bcego", "kennedyego"},
+		{"\ufb01lm", "film"},
+	}
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	for _, c := range cases {
+		t.Run(c.want, func(t *testing.T) {
+			have, err := g2p.Transcribe(c.have, true)
+			if err != nil {
+				t.Fatalf("failed to transcribe %q: %s", c.have, err)
+			}
+			want, err := g2p.Transcribe(c.want, true)
+			if err != nil {
+				t.Fatalf("failed to transcribe %q: %s", c.want, err)
+			}
+			if ok := reflect.DeepEqual(have, want); !ok {
+				t.Errorf("have %v; want: %v", have, want)
+			}
+		})
+	}
+}
+
+// Test if characters are mapped after case folding and normalization and
+// longer characters to map take precedence.
+func TestMap(t *testing.T) {
+	rules := `
+ALL = a, b, k, o
+EMPTY = *
+MAP Æ = a
+MAP x = k
+MAP xx = b
+MAP - =
+EMPTY	a	EMPTY	a
+EMPTY	b	EMPTY	b
+EMPTY	k	EMPTY	k
+EMPTY	o	EMPTY	o
+`
+	cases := []struct {
+		word string
+		want []string
+	}{
+		{"Æxo", []string{"a k o"}},
+		{"xxo", []string{"b o"}},
+		{"xxxo", []string{"b k o"}},
+		{"ko-ko", []string{"k o k o"}},
+	}
+	g2p, err := Load(strings.NewReader(rules))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	for _, c := range cases {
+		t.Run(c.word, func(t *testing.T) {
+			have, err := g2p.Transcribe(c.word, true)
+			if err != nil {
+				t.Fatalf("failed to transcribe %s: %s", c.word, err)
+			}
+			if ok := reflect.DeepEqual(have, c.want); !ok {
+				t.Errorf("have %v; want: %v", have, c.want)
+			}
+		})
+	}
+	if err := g2p.Eval("MAP y = o"); err != nil {
+		t.Fatalf("failed to evaluate map: %s", err)
+	}
+	if have, _ := g2p.Transcribe("ky", false); !reflect.DeepEqual(have, []string{"k o"}) {
+		t.Errorf("have %v; want: [k o]", have)
+	}
+}
+
+// Test if transcription gives up once the context is cancelled or its
+// deadline passes, also while a huge number of variants is enumerated.
+func TestTranscribeContext(t *testing.T) {
+	rules := `
+ALL = a
+EMPTY = *
+EMPTY	a	EMPTY	a, o, e, u
+`
+	g2p, err := Load(strings.NewReader(rules))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := g2p.TranscribeContext(ctx, "aa", true); !errors.Is(err, context.Canceled) {
+		t.Errorf("have %v; want: %v", err, context.Canceled)
+	}
+	if have, err := g2p.TranscribeContext(context.Background(), "aa", true); err != nil || len(have) != 16 {
+		t.Errorf("have %d variants, %v; want: 16", len(have), err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = g2p.TranscribeContext(ctx, strings.Repeat("a", 16), true)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("have %v; want: %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("enumeration of variants took %s after the deadline", d)
+	}
+}
@@ -2,9 +2,15 @@ package prg2p
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +18,31 @@ import (
 // to Scan() method.
 var errScan = errors.New("scanning error on nil interface")
 
+// includeDirective starts a line that reads rules from another file.
+//
+// Example:
+// #include "preamble.txt"
+const includeDirective = "#include"
+
+// mapKeyword starts a line that maps characters of words to other characters
+// before they are transcribed.
+//
+// Example:
+// MAP ﬁ = fi
+const mapKeyword = "MAP"
+
+// wildcard is the context matching any character including the word
+// boundary.
+const wildcard = "*"
+
+// Directives setting priorities of rules, declaring test cases and metadata
+// of the rule file.
+const (
+	priorityDirective = "@priority"
+	testDirective     = "@test"
+	metaDirective     = "@meta"
+)
+
 // Variable from an assignment statement with the name (left) and value (right)
 // side of the operator.
 //
@@ -32,18 +63,59 @@ type variable struct {
 // PUSTY	ś	-(b)	si
 // PUSTY	n	(ni, ci, dzi)	n, ni
 // (a, e)	u	PUSTY	l_
+// (s) SB	rz	PUSTY	sz
 type rule struct {
-	left   []string
-	right  []string
-	source string
-	target []string
+	left     []string
+	right    []string
+	source   string
+	target   []string
+	file     string   // Name of the rule file, empty if read from io.Reader.
+	line     int      // Line number in the rule file, 0 if evaluated ad hoc.
+	text     string   // Rule statement as it was written.
+	priority int      // Set with the @priority directive, 0 by default.
+	id       int      // Index of the rule in the order of evaluation.
+	vars     []string // Variables referred to in the contexts.
+}
+
+// specificity returns the number of alternative contexts of the rule. The
+// lower the number, the more specific the rule.
+func (r *rule) specificity() int {
+	return len(r.left) + len(r.right)
+}
+
+// segment returns the rule as a segment with the source grapheme and target
+// phonemes.
+func (r *rule) segment() Segment {
+	return Segment{
+		Grapheme: r.source,
+		Phonemes: r.target,
+		Rule:     r.text,
+		File:     r.file,
+		Line:     r.line,
+	}
 }
 
 // interpreter interprets G2P rules. It holds two components used to process
 // text into phonemic transcription: variables and rules.
+//
+// Files included with the #include directive are opened from fsys or, if it
+// is nil, from the operating system. Paths are resolved relative to the
+// directory of the including file.
 type interpreter struct {
-	vars  map[string][]string // Ex. key = ALL, value = a, b, c ... z
-	rules []rule
+	vars      map[string][]string // Ex. key = ALL, value = a, b, c ... z
+	rules     []rule
+	phonology []phonRule
+	sect      string           // Header of the section being evaluated.
+	priority  int              // Priority of rules set with @priority.
+	line      int              // Number of the line being evaluated by scan.
+	file      string           // Name of the file being evaluated by scan.
+	fsys      fs.FS            // File system to open included files from.
+	stack     []string         // Chain of included files used to detect cycles.
+	depth     int              // Number of #include directives being evaluated.
+	src       *strings.Builder // Lines evaluated by scan with includes inlined.
+	tests     []test
+	meta      map[string]string // Set with @meta and header directives.
+	maps      map[string]string // Set with the MAP keyword.
 }
 
 // newInterpreter returns a new Interpreter instance responsible for parsing
@@ -52,6 +124,9 @@ type interpreter struct {
 func newInterpreter() *interpreter {
 	i := &interpreter{
 		vars: make(map[string][]string),
+		sect: rulesSection,
+		meta: make(map[string]string),
+		maps: make(map[string]string),
 	}
 	return i
 }
@@ -62,21 +137,125 @@ func (i *interpreter) scan(r io.Reader) error {
 		return errScan
 	}
 	s := bufio.NewScanner(r)
+	prevLine, prevSect, prevPriority := i.line, i.sect, i.priority
+	i.inlineState(prevSect, prevPriority, rulesSection, 0)
+	i.line, i.sect, i.priority = 0, rulesSection, 0
+	defer func() {
+		i.inlineState(i.sect, i.priority, prevSect, prevPriority)
+		i.line, i.sect, i.priority = prevLine, prevSect, prevPriority
+	}()
 	for s.Scan() {
+		i.line++
 		l := s.Text()
 		l = strings.TrimSpace(l)
 		if err := i.eval(l); err != nil {
-			return fmt.Errorf("could not evaluate %s", l)
+			return fmt.Errorf("could not evaluate %s: %w", l, err)
 		}
+		i.inline(s.Text())
 	}
 	return nil
 }
 
+// inline writes the line l evaluated by scan to src. Lines of included files
+// take the place of the #include directive, while their metadata is left out
+// as it is ignored when they are included.
+func (i *interpreter) inline(l string) {
+	if i.src == nil || isInclude(strings.TrimSpace(l)) {
+		return
+	}
+	if d, err := parseDirective(strings.TrimSpace(l)); i.depth > 0 && err == nil && d.key != "" {
+		return
+	}
+	i.src.WriteString(l + "\n")
+}
+
+// inlineState writes the section header and the @priority directive to src
+// that switch from the section and priority of rules the inlined lines leave
+// off with to the ones that the lines that follow expect, since they are
+// reset for each file that is scanned.
+func (i *interpreter) inlineState(sect string, priority int, nextSect string, nextPriority int) {
+	if i.src == nil {
+		return
+	}
+	if sect != nextSect {
+		i.src.WriteString(nextSect + "\n")
+	}
+	if priority != nextPriority {
+		i.src.WriteString(priorityDirective + " " + strconv.Itoa(nextPriority) + "\n")
+	}
+}
+
+// scanFile populates Interpreter with G2P rules read from the file name.
+// Files with the .json, .yaml or .yml extension are decoded as rule sets.
+func (i *interpreter) scanFile(name string) error {
+	for _, f := range i.stack {
+		if f == name {
+			chain := append(i.stack, name)
+			return fmt.Errorf("include cycle %s", strings.Join(chain, " -> "))
+		}
+	}
+	var (
+		f   io.ReadCloser
+		err error
+	)
+	if i.fsys != nil {
+		f, err = i.fsys.Open(name)
+	} else {
+		f, err = os.Open(name)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	prev := i.file
+	i.file, i.stack = name, append(i.stack, name)
+	defer func() {
+		i.file, i.stack = prev, i.stack[:len(i.stack)-1]
+	}()
+	if !isStructured(name) {
+		return i.scan(f)
+	}
+	rs, err := DecodeRuleSet(f)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if _, err := rs.WriteTo(&buf); err != nil {
+		return err
+	}
+	return i.scan(&buf)
+}
+
+// isStructured reports whether the file name holds a rule set in JSON or
+// YAML judging by its extension.
+func isStructured(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
 // eval evaluates a line as a variable or a rule.
 func (i *interpreter) eval(l string) error {
+	if isInclude(l) {
+		return i.include(l)
+	}
 	if l == "" || strings.HasPrefix(l, "#") {
 		return nil
 	}
+	if strings.HasPrefix(l, "@") {
+		return i.directive(l)
+	}
+	if strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]") {
+		return i.section(l)
+	}
+	if isMap(l) {
+		return i.asMap(l)
+	}
+	if i.sect == phonologySection && !strings.Contains(l, "=") {
+		return i.asPhonRule(l)
+	}
 	if ok := strings.Contains(l, "="); ok {
 		err := i.asVar(l)
 		if err != nil {
@@ -91,6 +270,142 @@ func (i *interpreter) eval(l string) error {
 	return nil
 }
 
+// include evaluates rules from the file named in the #include directive.
+func (i *interpreter) include(l string) error {
+	arg := strings.TrimSpace(strings.TrimPrefix(l, includeDirective))
+	name, err := strconv.Unquote(arg)
+	if err != nil || name == "" {
+		return fmt.Errorf("expected quoted file name in line %s", l)
+	}
+	if i.fsys != nil {
+		name = path.Join(path.Dir(i.file), name)
+	} else if !filepath.IsAbs(name) {
+		name = filepath.Join(filepath.Dir(i.file), name)
+	}
+	i.depth++
+	defer func() { i.depth-- }()
+	return i.scanFile(name)
+}
+
+// directive evaluates a line starting with "@" that sets a property of the
+// rules that follow it in the same file, declares a test case or sets
+// metadata of the rule file. Metadata of included files is ignored so that
+// it cannot replace that of the including file, but the version they
+// require with @requires is still checked.
+func (i *interpreter) directive(l string) error {
+	d, err := parseDirective(l)
+	if err != nil {
+		return fmt.Errorf("%w in line %s", err, l)
+	}
+	switch d.name {
+	case priorityDirective:
+		i.priority = d.priority
+	case testDirective:
+		i.tests = append(i.tests, test{TestCase: d.test, file: i.file, line: i.line})
+	case "@requires":
+		if i.depth > 0 {
+			return checkRequires(d.val)
+		}
+		i.meta[d.key] = d.val
+	default:
+		if i.depth == 0 {
+			i.meta[d.key] = d.val
+		}
+	}
+	return nil
+}
+
+// directive is a parsed line starting with "@".
+type directive struct {
+	name     string   // Name of the directive including "@".
+	priority int      // Set with @priority.
+	test     TestCase // Set with @test.
+	key, val string   // Set with @meta and header directives.
+}
+
+// parseDirective parses a line starting with "@". It is shared by the
+// interpreter and ReadRuleSet so that both accept the same directives.
+//
+// Examples:
+// @priority 10
+// @test bok b o k | b o g
+// @meta dialect standard
+// @version 1.2.0
+func parseDirective(l string) (directive, error) {
+	name, arg, _ := strings.Cut(l, " ")
+	arg = strings.TrimSpace(arg)
+	d := directive{name: name}
+	switch name {
+	case priorityDirective:
+		p, err := strconv.Atoi(arg)
+		if err != nil {
+			return d, fmt.Errorf("expected integer priority")
+		}
+		d.priority = p
+	case testDirective:
+		tc, err := parseTest(arg)
+		if err != nil {
+			return d, err
+		}
+		d.test = tc
+	case metaDirective:
+		key, val, _ := strings.Cut(arg, " ")
+		if key == "" {
+			return d, fmt.Errorf("expected key and value")
+		}
+		d.key, d.val = key, strings.TrimSpace(val)
+	case "@name", "@version", "@author", "@language", "@phoneset", "@requires":
+		key, err := parseHeader(name, arg)
+		if err != nil {
+			return d, err
+		}
+		d.key, d.val = key, arg
+	default:
+		return d, fmt.Errorf("unknown directive %s", name)
+	}
+	return d, nil
+}
+
+// isInclude reports whether the line l is the #include directive rather than
+// a comment that happens to start with the same letters, such as "#includes".
+func isInclude(l string) bool {
+	rest := strings.TrimPrefix(l, includeDirective)
+	return len(rest) < len(l) && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// isMap reports whether the line l maps characters with the MAP keyword. The
+// keyword must be followed by the characters to map and "=", so that MAP can
+// still name a variable assigned or used as a context of a rule.
+func isMap(l string) bool {
+	if !strings.HasPrefix(l, mapKeyword+" ") && !strings.HasPrefix(l, mapKeyword+"\t") {
+		return false
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(l, mapKeyword))
+	return strings.Contains(rest, "=") && !strings.HasPrefix(rest, "=")
+}
+
+// asMap evaluates a line mapping characters of words to other characters.
+// The replacement may be empty to delete the characters.
+func (i *interpreter) asMap(l string) error {
+	from, to, err := parseMap(l)
+	if err != nil {
+		return err
+	}
+	i.maps[from] = to
+	return nil
+}
+
+// parseMap returns the characters mapped by the line l and their
+// replacement.
+func parseMap(l string) (string, string, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(l, mapKeyword)), "=")
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !ok || from == "" {
+		return "", "", fmt.Errorf("expected characters to map in line %s", l)
+	}
+	return from, to, nil
+}
+
 // asVar evaluates a line as a variable assignment.
 func (i *interpreter) asVar(l string) error {
 	sp := strings.Split(l, "=")
@@ -98,6 +413,14 @@ func (i *interpreter) asVar(l string) error {
 		return fmt.Errorf("multiple assignments on one line %s", l)
 	}
 	vr := strings.TrimSpace(sp[0])
+	if i.isExpr(sp[1]) {
+		vals, err := i.expr(sp[1])
+		if err != nil {
+			return err
+		}
+		i.vars[vr] = vals
+		return nil
+	}
 	vals := strings.Split(sp[1], ",")
 	if len(vals) == 1 && strings.TrimSpace(vals[0]) == "" {
 		return fmt.Errorf("no values to assign to variable on line %s", l)
@@ -105,16 +428,17 @@ func (i *interpreter) asVar(l string) error {
 	for i := range vals {
 		vals[i] = strings.TrimSpace(vals[i])
 	}
-	if vr == "ALL" {
+	if vr == "ALL" && !contains(vals, "$") {
 		vals = append(vals, "$")
 	}
 	i.vars[vr] = vals
 	return nil
 }
 
-// asRule evaluates a line as a rule statement.
+// asRule evaluates a line as a rule statement. Columns are separated with
+// one or more tabs and spaces around them are ignored.
 func (i *interpreter) asRule(l string) error {
-	splits := strings.Split(l, "\t")
+	splits := columns(l)
 	if len(splits) != 4 {
 		return fmt.Errorf("expected 4 splits in %s", l)
 	}
@@ -138,89 +462,72 @@ func (i *interpreter) asRule(l string) error {
 		return fmt.Errorf("empty right context in line %s", l)
 	}
 	r := rule{
-		left:   lCtx,
-		right:  rCtx,
-		source: splits[1],
-		target: target,
+		left:     lCtx,
+		right:    rCtx,
+		source:   splits[1],
+		target:   target,
+		file:     i.file,
+		line:     i.line,
+		text:     l,
+		priority: i.priority,
+		id:       len(i.rules),
+		vars:     i.refs(splits[0], splits[2]),
 	}
 	i.rules = append(i.rules, r)
 	return nil
 }
 
-// context returns the left/right context for the source character.
+// columns splits the rule line l on tabs dropping empty columns and spaces
+// around them.
+func columns(l string) []string {
+	var out []string
+	for _, c := range strings.Split(l, "\t") {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// context returns the left/right context for the source character. Contexts
+// of more than one symbol are written as sequences of set expressions and
+// returned as concatenations of their values, for example, the right context
+// "(s) (b, p)" results in "sb" and "sp". A variable with the value "*" or the
+// bare "*" matches any context.
 func (i *interpreter) context(v string) ([]string, error) {
-	if s, ok := i.vars[v]; ok && strings.Join(s, "") == "*" {
+	if v == wildcard {
+		return nil, nil
+	}
+	if s, ok := i.vars[v]; ok && strings.Join(s, "") == wildcard {
 		return nil, nil
 	}
 	if _, ok := i.vars["ALL"]; !ok { // "ALL" is the base slice to trim.
 		return nil, fmt.Errorf("variable \"ALL\" not set")
 	}
-	var out []string
-	var err error
-	if strings.HasPrefix(v, "-") {
-		out, err = i.asDifference(v)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		out, err = i.asConstraint(v)
-		if err != nil {
-			return nil, err
-		}
+	seq, err := i.sequence(v)
+	if err != nil {
+		return nil, err
 	}
-	return out, nil
-}
-
-// asDifference returns a limited set of values by removing unwanted values
-// them from ALL.
-func (i *interpreter) asDifference(v string) ([]string, error) {
-	if strings.HasPrefix(v, "-(") {
-		if !strings.HasSuffix(v, ")") {
-			return nil, fmt.Errorf("expected \")\" in line %s", v)
-		}
-		var toRemove []string
-		for _, c := range strings.Split(v[2:len(v)-1], ",") {
-			c = strings.TrimSpace(c)
-			toRemove = append(toRemove, c)
+	for _, set := range seq {
+		if len(set) == 0 {
+			return nil, fmt.Errorf("context %s matches no characters", v)
 		}
-		out := rm(i.vars["ALL"], toRemove)
-		return out, nil
-	}
-	out := i.vars["ALL"]
-	for _, vr := range strings.Split(v, "-")[1:] {
-		vals, ok := i.vars[vr]
-		if !ok {
-			return nil, fmt.Errorf("variable \"%s\" not found", vr)
-		}
-		out = rm(out, vals)
 	}
-	return out, nil
+	return product(seq), nil
 }
 
-// asConstraint returns a limited set of values.
-func (i *interpreter) asConstraint(v string) ([]string, error) {
-	if strings.HasPrefix(v, "(") {
-		if !strings.HasSuffix(v, ")") {
-			return nil, fmt.Errorf("expected \")\" in line %s", v)
-		}
-		var toKeep []string
-		for _, c := range strings.Split(v[1:len(v)-1], ",") {
-			c = strings.TrimSpace(c)
-			toKeep = append(toKeep, c)
-		}
-		return toKeep, nil
-	}
+// refs returns names of variables referred to in the expressions exprs in
+// the order of their first occurrence.
+func (i *interpreter) refs(exprs ...string) []string {
 	var out []string
-	for _, vr := range strings.Split(v, "+") {
-		vals, ok := i.vars[vr]
-		if !ok {
-			return nil, fmt.Errorf("variable \"%s\" not found", vr)
-		}
-		for _, c := range vals {
-			out = append(out, c)
+	for _, e := range exprs {
+		for _, t := range lex(e) {
+			if _, ok := i.vars[t.val]; ok && t.kind == tokName && !contains(out, t.val) {
+				out = append(out, t.val)
+			}
 		}
 	}
-	return out, nil
+	return out
 }
 
 // rm removes items from the first slice if present in the second slice.
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func rulesIO() *strings.Reader {
@@ -300,7 +301,7 @@ func TestEval(t *testing.T) {
 	}
 	invalid := []string{
 		"END	cz	SA2	czi	dzi", // Five elements instead of four
-		"ALL = dz, cz = SB", // Multiple assignments on one line
+		"ALL = dz, cz = SB",  // Multiple assignments on one line
 	}
 	for _, l := range valid {
 		err := i.eval(l)
@@ -401,7 +402,7 @@ func TestRmFromSlice(t *testing.T) {
 }
 
 // Check if constrained context is created correctly.
-func TestAsConstraint(t *testing.T) {
+func TestExprConstraint(t *testing.T) {
 	inputs := []string{
 		"(p)",
 		"(a, e, i, o, u, y)",
@@ -423,15 +424,15 @@ func TestAsConstraint(t *testing.T) {
 
 	for i := 0; i < len(inputs); i++ {
 		has := expected[i]
-		want, _ := I.asConstraint(inputs[i])
+		want, _ := I.expr(inputs[i])
 		if ok := reflect.DeepEqual(has, want); !ok {
 			t.Errorf("error: want %v; has %v", want, has)
 		}
 	}
 }
 
-// asConstraint throws an error when context is not enclosed in ( ).
-func TestAsConstraintError(t *testing.T) {
+// expr throws an error when a union is not enclosed in ( ).
+func TestExprConstraintError(t *testing.T) {
 	cases := []struct {
 		name, ctx string
 	}{
@@ -443,7 +444,7 @@ func TestAsConstraintError(t *testing.T) {
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			_, err := i.asConstraint(c.ctx)
+			_, err := i.expr(c.ctx)
 			if err == nil {
 				t.Errorf("expected method to fail on input %s", c.ctx)
 			}
@@ -452,7 +453,7 @@ func TestAsConstraintError(t *testing.T) {
 }
 
 // Check if difference context is established as specified.
-func TestAsDifference(t *testing.T) {
+func TestExprDifference(t *testing.T) {
 	I := interpreter{
 		vars: make(map[string][]string),
 	}
@@ -476,15 +477,15 @@ func TestAsDifference(t *testing.T) {
 
 	for i := 0; i < len(inputs); i++ {
 		has := expected[i]
-		want, _ := I.asDifference(inputs[i])
+		want, _ := I.expr(inputs[i])
 		if ok := reflect.DeepEqual(has, want); !ok {
 			t.Errorf("error: want %v; has %v", want, has)
 		}
 	}
 }
 
-// asDifference throws an error when context is not enclosed in ( ).
-func TestAsDifferenceError(t *testing.T) {
+// expr throws an error when a difference is not enclosed in ( ).
+func TestExprDifferenceError(t *testing.T) {
 	cases := []struct {
 		name, ctx string
 	}{
@@ -496,7 +497,7 @@ func TestAsDifferenceError(t *testing.T) {
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			_, err := i.asDifference(c.ctx)
+			_, err := i.expr(c.ctx)
 			if err == nil {
 				t.Errorf("expected method to fail on input %s", c.ctx)
 			}
@@ -548,3 +549,209 @@ func TestContextError(t *testing.T) {
 		t.Errorf("error was not raised when \"ALL\" is not set")
 	}
 }
+
+// Check if the #include directive reads rules from files resolved relative
+// to the including file.
+func TestInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"common/preamble.txt": {Data: []byte("ALL = a, b, p\nPUSTY = *\nEND = $\n")},
+		"pl/rules.txt": {Data: []byte(`#include "../common/preamble.txt"
+PUSTY	b	END	p`)},
+	}
+	i := newInterpreter()
+	i.fsys = fsys
+	if err := i.scanFile("pl/rules.txt"); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, ok := i.vars["ALL"]; !ok {
+		t.Errorf("variable \"ALL\" was not included")
+	}
+	if len(i.rules) != 1 {
+		t.Fatalf("have %d rules; want 1", len(i.rules))
+	}
+	if r := i.rules[0]; r.file != "pl/rules.txt" || r.line != 2 {
+		t.Errorf("have %s:%d; want pl/rules.txt:2", r.file, r.line)
+	}
+}
+
+// Check if lines of included files are inlined in place of the #include
+// directive so that the result means the same as the including file.
+func TestIncludeInline(t *testing.T) {
+	fsys := fstest.MapFS{
+		"parent.txt": {Data: []byte("@name parent\n@priority 2\n#include \"child.txt\"\nPUSTY\tb\tPUSTY\tb")},
+		"child.txt": {Data: []byte(`@name child
+ALL = a, b, $
+PUSTY = *
+PUSTY	a	PUSTY	a
+[PHONOLOGY]
+a -> e / _ #`)},
+	}
+	i := newInterpreter()
+	i.fsys, i.src = fsys, &strings.Builder{}
+	if err := i.scanFile("parent.txt"); err != nil {
+		t.Fatalf("%v", err)
+	}
+	inlined := newInterpreter()
+	if err := inlined.scan(strings.NewReader(i.src.String())); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if have := inlined.meta["name"]; have != "parent" {
+		t.Errorf("have name %s; want: parent", have)
+	}
+	if len(inlined.rules) != 2 || len(inlined.phonology) != 1 {
+		t.Fatalf("have %d rules and %d phonological rules; want 2 and 1", len(inlined.rules), len(inlined.phonology))
+	}
+	for k, r := range inlined.rules {
+		if want := i.rules[k].priority; r.priority != want {
+			t.Errorf("have priority %d of %s; want: %d", r.priority, r.text, want)
+		}
+	}
+}
+
+// Check if comments starting with the letters of the #include directive are
+// not read as the directive.
+func TestIncludeComment(t *testing.T) {
+	rules := "#includes the preamble below\n#included files come first\nALL = a, $\nPUSTY = *\nPUSTY\ta\tPUSTY\ta"
+	i := newInterpreter()
+	if err := i.scan(strings.NewReader(rules)); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(i.rules) != 1 {
+		t.Errorf("have %d rules; want 1", len(i.rules))
+	}
+}
+
+// Check if the #include directive fails on cycles, missing files and
+// malformed file names.
+func TestIncludeFails(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":        {Data: []byte(`#include "b.txt"`)},
+		"b.txt":        {Data: []byte(`#include "a.txt"`)},
+		"self.txt":     {Data: []byte(`#include "self.txt"`)},
+		"missing.txt":  {Data: []byte(`#include "nowhere.txt"`)},
+		"unquoted.txt": {Data: []byte(`#include b.txt`)},
+	}
+	cases := []string{"a.txt", "self.txt", "missing.txt", "unquoted.txt"}
+	for _, c := range cases {
+		t.Run(c, func(t *testing.T) {
+			i := newInterpreter()
+			i.fsys = fsys
+			if err := i.scanFile(c); err == nil {
+				t.Errorf("expected scanning %s to fail", c)
+			}
+		})
+	}
+}
+
+// Check if contexts of more than one symbol are expanded to concatenations
+// of their values.
+func TestContextSequence(t *testing.T) {
+	cases := []struct {
+		name, ctx string
+		want      []string
+	}{
+		{"two-vars", "SB SD", []string{"czb", "czd", "szb", "szd"}},
+		{"literal-var", "(s) SB", []string{"scz", "ssz"}},
+		{"end", "SD END", []string{"b$", "d$"}},
+		{"complement", "(s) (-SD-SB)", []string{"sa", "s$"}},
+		{"difference", "SB -SD", []string{"cz", "sz"}},
+	}
+	I := interpreter{
+		vars: make(map[string][]string),
+	}
+	I.vars["ALL"] = []string{"a", "b", "d", "cz", "sz", "$"}
+	I.vars["PUSTY"] = []string{"*"}
+	I.vars["SB"] = []string{"cz", "sz"}
+	I.vars["SD"] = []string{"b", "d"}
+	I.vars["END"] = []string{"$"}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			have, err := I.context(c.ctx)
+			if err != nil {
+				t.Fatalf("failed to evaluate %s: %s", c.ctx, err)
+			}
+			if ok := reflect.DeepEqual(have, c.want); !ok {
+				t.Errorf("have %v; want: %v", have, c.want)
+			}
+		})
+	}
+	for _, ctx := range []string{"SB PUSTY", "SB XX", "SB (a", "SB -SB"} {
+		if _, err := I.context(ctx); err == nil {
+			t.Errorf("expected context %s to fail", ctx)
+		}
+	}
+}
+
+// Check if the @priority directive applies to rules that follow it.
+func TestDirectivePriority(t *testing.T) {
+	i := newInterpreter()
+	r := strings.NewReader(`ALL = a, b
+PUSTY = *
+PUSTY	a	PUSTY	a
+@priority 5
+PUSTY	b	PUSTY	b
+@priority -1
+PUSTY	b	(a)	p`)
+	if err := i.scan(r); err != nil {
+		t.Fatalf("%v", err)
+	}
+	want := []int{0, 5, -1}
+	for k, r := range i.rules {
+		if r.priority != want[k] {
+			t.Errorf("rule %d: have priority %d; want %d", k, r.priority, want[k])
+		}
+	}
+	if i.priority != 0 {
+		t.Errorf("have priority %d after scan; want 0", i.priority)
+	}
+	for _, l := range []string{"@priority high", "@priority", "@unknown 1"} {
+		if err := i.eval(l); err == nil {
+			t.Errorf("expected %s to fail", l)
+		}
+	}
+}
+
+// Test if lines starting with the MAP keyword map characters.
+func TestAsMap(t *testing.T) {
+	i := newInterpreter()
+	for _, l := range []string{"MAP ﬁ = fi", "MAP\t’ =", "MAP ’ = '"} {
+		if err := i.eval(l); err != nil {
+			t.Errorf("failed to evaluate %s: %s", l, err)
+		}
+	}
+	want := map[string]string{"ﬁ": "fi", "’": "'"}
+	if ok := reflect.DeepEqual(i.maps, want); !ok {
+		t.Errorf("have %v; want: %v", i.maps, want)
+	}
+	for _, l := range []string{"MAP ﬁ fi", "MAP =fi= fi"} {
+		if err := i.eval(l); err == nil {
+			t.Errorf("%s should cause an error", l)
+		}
+	}
+}
+
+// Test if MAP can still name a variable assigned and used as a context.
+func TestMapVariable(t *testing.T) {
+	rules := "ALL = a, b, $\nEMPTY = *\nMAP = a, b\nEMPTY\ta\tEMPTY\ta\nEMPTY\tb\tEMPTY\tb\nMAP\tb\tEMPTY\tp\n"
+	g2p, err := Load(strings.NewReader(rules))
+	if err != nil {
+		t.Fatalf("failed to load rules: %s", err)
+	}
+	if have := g2p.Vars()["MAP"]; !reflect.DeepEqual(have, []string{"a", "b"}) {
+		t.Errorf("have %v; want: [a b]", have)
+	}
+	have, err := g2p.Transcribe("ab", false)
+	if err != nil {
+		t.Fatalf("failed to transcribe: %s", err)
+	}
+	if ok := reflect.DeepEqual(have, []string{"a p"}); !ok {
+		t.Errorf("have %v; want: [a p]", have)
+	}
+	rs, err := ReadRuleSet(strings.NewReader(rules))
+	if err != nil {
+		t.Fatalf("failed to read rule set: %s", err)
+	}
+	if rs.Map != nil {
+		t.Errorf("have %v; want no mappings", rs.Map)
+	}
+}
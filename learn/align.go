@@ -0,0 +1,202 @@
+package learn
+
+import (
+	"math"
+	"strings"
+)
+
+// Pair is a chunk of graphemes aligned with the phonemes it is pronounced as.
+type Pair struct {
+	Grapheme string
+	Phonemes []string
+}
+
+// Alignment is a word of the lexicon split into aligned pairs in the order
+// they appear in the word.
+type Alignment []Pair
+
+// Word returns the word spelled by the alignment.
+func (a Alignment) Word() string {
+	var b strings.Builder
+	for _, p := range a {
+		b.WriteString(p.Grapheme)
+	}
+	return b.String()
+}
+
+// key identifies a pair of a grapheme chunk and a phoneme chunk.
+type key struct {
+	grapheme, phonemes string
+}
+
+// span is the pair k ending after i graphemes and j phonemes of a word.
+type span struct {
+	k    key
+	i, j int
+}
+
+// chunkPenalty scales the probability of a pair for each grapheme or
+// phoneme beyond the first one.
+const chunkPenalty = 0.1
+
+// model holds joint probabilities of grapheme and phoneme chunks.
+type model map[key]float64
+
+// aligner aligns lexicon entries with the expectation maximization over all
+// segmentations of words into chunks of up to maxG graphemes and maxP
+// phonemes. Chunks of more than one grapheme and more than one phoneme at
+// the same time are not considered.
+type aligner struct {
+	maxG, maxP int
+	m          model
+}
+
+// Align aligns graphemes of lexicon entries with their phonemes. Entries that
+// cannot be split into chunks allowed by o are left out.
+func Align(lex []Entry, o Options) []Alignment {
+	o = o.withDefaults()
+	a := aligner{maxG: o.MaxGraphemes, maxP: o.MaxPhonemes, m: make(model)}
+	for _, e := range lex {
+		a.each(e, func(k key, _, _ int) { a.m[k] = 1 })
+	}
+	a.m.normalize()
+	for n := 0; n < o.Iterations; n++ {
+		counts := make(model)
+		for _, e := range lex {
+			a.expect(e, counts)
+		}
+		counts.normalize()
+		a.m = counts
+	}
+	var out []Alignment
+	for _, e := range lex {
+		if al := a.viterbi(e); al != nil {
+			out = append(out, al)
+		}
+	}
+	return out
+}
+
+// each calls f for every pair of chunks that can be aligned in the entry e
+// with the pair ending after i graphemes and j phonemes.
+func (a *aligner) each(e Entry, f func(k key, i, j int)) {
+	w := []rune(e.Word)
+	for i := 0; i <= len(w); i++ {
+		for j := 0; j <= len(e.Phonemes); j++ {
+			for g := 1; g <= a.maxG && g <= i; g++ {
+				for p := 1; p <= a.maxP && p <= j; p++ {
+					if g > 1 && p > 1 {
+						continue
+					}
+					k := key{string(w[i-g : i]), strings.Join(e.Phonemes[j-p:j], " ")}
+					f(k, i, j)
+				}
+			}
+		}
+	}
+}
+
+// chunks returns the number of graphemes and phonemes in the pair k.
+func (k key) chunks() (int, int) {
+	return len([]rune(k.grapheme)), len(strings.Fields(k.phonemes))
+}
+
+// expect adds expected counts of pairs in the entry e to counts.
+func (a *aligner) expect(e Entry, counts model) {
+	n, m := len([]rune(e.Word)), len(e.Phonemes)
+	fwd := grid(n, m)
+	fwd[0][0] = 1
+	a.each(e, func(k key, i, j int) {
+		g, p := k.chunks()
+		fwd[i][j] += fwd[i-g][j-p] * a.weight(k)
+	})
+	total := fwd[n][m]
+	if total == 0 {
+		return
+	}
+	bwd := grid(n, m)
+	bwd[n][m] = 1
+	var pairs []span
+	a.each(e, func(k key, i, j int) {
+		pairs = append(pairs, span{k, i, j})
+	})
+	for x := len(pairs) - 1; x >= 0; x-- {
+		k, i, j := pairs[x].k, pairs[x].i, pairs[x].j
+		g, p := k.chunks()
+		bwd[i-g][j-p] += a.weight(k) * bwd[i][j]
+	}
+	for _, pr := range pairs {
+		g, p := pr.k.chunks()
+		counts[pr.k] += fwd[pr.i-g][pr.j-p] * a.weight(pr.k) * bwd[pr.i][pr.j] / total
+	}
+}
+
+// viterbi returns the most probable alignment of the entry e or nil if the
+// entry cannot be aligned.
+func (a *aligner) viterbi(e Entry) Alignment {
+	n, m := len([]rune(e.Word)), len(e.Phonemes)
+	best := grid(n, m)
+	back := make([][]key, n+1)
+	for i := range best {
+		back[i] = make([]key, m+1)
+		for j := range best[i] {
+			best[i][j] = math.Inf(-1)
+		}
+	}
+	best[0][0] = 0
+	a.each(e, func(k key, i, j int) {
+		p := a.weight(k)
+		if p == 0 {
+			return
+		}
+		g, f := k.chunks()
+		if s := best[i-g][j-f] + math.Log(p); s > best[i][j] {
+			best[i][j], back[i][j] = s, k
+		}
+	})
+	if math.IsInf(best[n][m], -1) {
+		return nil
+	}
+	var out Alignment
+	for i, j := n, m; i > 0 || j > 0; {
+		k := back[i][j]
+		out = append(out, Pair{Grapheme: k.grapheme, Phonemes: strings.Fields(k.phonemes)})
+		g, p := k.chunks()
+		i, j = i-g, j-p
+	}
+	for l, r := 0, len(out)-1; l < r; l, r = l+1, r-1 {
+		out[l], out[r] = out[r], out[l]
+	}
+	return out
+}
+
+// weight returns the probability of the pair k penalized for every grapheme
+// or phoneme it holds beyond one of each, so that longer chunks are only used
+// where single letters cannot be aligned.
+func (a *aligner) weight(k key) float64 {
+	g, p := k.chunks()
+	return a.m[k] * math.Pow(chunkPenalty, float64(g+p-2))
+}
+
+// normalize turns counts of the model into probabilities.
+func (m model) normalize() {
+	var total float64
+	for _, v := range m {
+		total += v
+	}
+	if total == 0 {
+		return
+	}
+	for k := range m {
+		m[k] /= total
+	}
+}
+
+// grid returns a zeroed table of n+1 rows and m+1 columns.
+func grid(n, m int) [][]float64 {
+	out := make([][]float64, n+1)
+	for i := range out {
+		out[i] = make([]float64, m+1)
+	}
+	return out
+}
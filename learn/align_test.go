@@ -0,0 +1,45 @@
+package learn
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test if Align splits words into single letters where possible and into
+// longer chunks where letters outnumber phonemes.
+func TestAlign(t *testing.T) {
+	lex, _ := lexicon(t)
+	cases := map[string]Alignment{
+		"ala": {{"a", []string{"a"}}, {"l", []string{"l"}}, {"a", []string{"a"}}},
+		"kot": {{"k", []string{"k"}}, {"o", []string{"o"}}, {"t", []string{"t"}}},
+		"szkoła": {
+			{"sz", []string{"sz"}}, {"k", []string{"k"}}, {"o", []string{"o"}},
+			{"ł", []string{"l_"}}, {"a", []string{"a"}},
+		},
+		"chata": {{"ch", []string{"h"}}, {"a", []string{"a"}}, {"t", []string{"t"}}, {"a", []string{"a"}}},
+	}
+	have := make(map[string]Alignment)
+	for _, al := range Align(lex, Options{}) {
+		have[al.Word()] = al
+	}
+	for w, want := range cases {
+		t.Run(w, func(t *testing.T) {
+			if ok := reflect.DeepEqual(have[w], want); !ok {
+				t.Errorf("have %v; want: %v", have[w], want)
+			}
+		})
+	}
+}
+
+// Test if entries that cannot be split into allowed chunks are left out.
+func TestAlignUnaligned(t *testing.T) {
+	lex := []Entry{
+		{Word: "ala", Phonemes: []string{"a", "l", "a"}},
+		{Word: "a", Phonemes: []string{"a", "l", "a"}},
+		{Word: "alamakota", Phonemes: []string{"a"}},
+	}
+	have := Align(lex, Options{})
+	if len(have) != 1 || have[0].Word() != "ala" {
+		t.Errorf("have %v; want only ala aligned", have)
+	}
+}
@@ -0,0 +1,226 @@
+package learn
+
+import (
+	"sort"
+	"strings"
+)
+
+// Names of variables declared in every induced rule file.
+const (
+	anyVar = "EMPTY"
+	endVar = "END"
+)
+
+// Rule is an induced rule in the four-column format of prg2p. Count is the
+// number of aligned occurrences of the source pronounced as the first target
+// in the given context.
+type Rule struct {
+	Left, Source, Right string
+	Targets             []string
+	Count               int
+}
+
+// String returns the rule as a tab-separated rule file line.
+func (r Rule) String() string {
+	return r.Left + "\t" + r.Source + "\t" + r.Right + "\t" + strings.Join(r.Targets, ", ")
+}
+
+// Rules is an induced rule set with the variables its contexts refer to.
+type Rules struct {
+	Alphabet []string            // Letters of the words the rules were learned from.
+	Vars     map[string][]string // Variables used in contexts of the rules.
+	Rules    []Rule
+}
+
+// Format returns the rules in the rule file format accepted by prg2p.Load.
+func (r *Rules) Format() string {
+	var b strings.Builder
+	b.WriteString("# Rules induced from a pronunciation lexicon.\n")
+	b.WriteString("ALL = " + strings.Join(r.Alphabet, ", ") + "\n")
+	b.WriteString(anyVar + " = *\n")
+	var names []string
+	for k := range r.Vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		b.WriteString(k + " = " + strings.Join(r.Vars[k], ", ") + "\n")
+	}
+	b.WriteString("\n")
+	for _, rl := range r.Rules {
+		b.WriteString(rl.String() + "\n")
+	}
+	return b.String()
+}
+
+// occurrence is an aligned grapheme chunk with the characters around it,
+// where "$" stands for the word boundary.
+type occurrence struct {
+	left, right string
+	phonemes    string
+}
+
+// Induce proposes rules for grapheme chunks of the alignments als. Chunks of
+// more than one grapheme and alternative pronunciations seen fewer than
+// o.MinCount times are left out.
+func Induce(als []Alignment, o Options) *Rules {
+	o = o.withDefaults()
+	occs := make(map[string][]occurrence)
+	letters := make(map[string]bool)
+	for _, al := range als {
+		w := []rune(al.Word())
+		i := 0
+		for _, p := range al {
+			n := len([]rune(p.Grapheme))
+			oc := occurrence{left: "$", right: "$", phonemes: strings.Join(p.Phonemes, " ")}
+			if i > 0 {
+				oc.left = string(w[i-1])
+			}
+			if i+n < len(w) {
+				oc.right = string(w[i+n])
+			}
+			occs[p.Grapheme] = append(occs[p.Grapheme], oc)
+			i += n
+		}
+		for _, c := range w {
+			letters[string(c)] = true
+		}
+	}
+
+	out := Rules{Vars: make(map[string][]string)}
+	for c := range letters {
+		out.Alphabet = append(out.Alphabet, c)
+	}
+	sort.Strings(out.Alphabet)
+
+	vars := candidates(o.Vars)
+	var sources []string
+	for g := range occs {
+		sources = append(sources, g)
+	}
+	sort.Strings(sources)
+	for _, g := range sources {
+		if len([]rune(g)) > 1 && len(occs[g]) < o.MinCount {
+			continue
+		}
+		out.Rules = append(out.Rules, out.induce(g, occs[g], vars, o.MinCount)...)
+	}
+	return &out
+}
+
+// induce returns rules for the source g with the occurrences occs. The most
+// frequent pronunciation gets the default rule and the other ones get a
+// right or a left context setting them apart. Pronunciations that cannot be
+// set apart by a single character of context become variants of the default
+// rule.
+func (r *Rules) induce(g string, occs []occurrence, vars map[string][]string, minCount int) []Rule {
+	counts := make(map[string]int)
+	var prons []string
+	for _, oc := range occs {
+		if counts[oc.phonemes] == 0 {
+			prons = append(prons, oc.phonemes)
+		}
+		counts[oc.phonemes]++
+	}
+	sort.SliceStable(prons, func(i, j int) bool {
+		if counts[prons[i]] != counts[prons[j]] {
+			return counts[prons[i]] > counts[prons[j]]
+		}
+		return prons[i] < prons[j]
+	})
+	def := Rule{Left: anyVar, Source: g, Right: anyVar, Targets: prons[:1], Count: counts[prons[0]]}
+	out := []Rule{def}
+	for _, p := range prons[1:] {
+		if counts[p] < minCount {
+			continue
+		}
+		lp, lo, rp, ro := contexts(occs, p)
+		switch {
+		case disjoint(rp, ro):
+			out = append(out, Rule{Left: anyVar, Source: g, Right: r.context(rp, ro, vars), Targets: []string{p}, Count: counts[p]})
+		case disjoint(lp, lo):
+			out = append(out, Rule{Left: r.context(lp, lo, vars), Source: g, Right: anyVar, Targets: []string{p}, Count: counts[p]})
+		default:
+			out[0].Targets = append(out[0].Targets, p)
+		}
+	}
+	return out
+}
+
+// contexts returns left and right characters around occurrences pronounced
+// as p and around the other occurrences.
+func contexts(occs []occurrence, p string) (lp, lo, rp, ro map[string]bool) {
+	lp, lo = make(map[string]bool), make(map[string]bool)
+	rp, ro = make(map[string]bool), make(map[string]bool)
+	for _, oc := range occs {
+		if oc.phonemes == p {
+			lp[oc.left], rp[oc.right] = true, true
+			continue
+		}
+		lo[oc.left], ro[oc.right] = true, true
+	}
+	return lp, lo, rp, ro
+}
+
+// context returns the name of the smallest variable holding all characters
+// of in and none of out or, if there is no such variable, a literal set of
+// characters of in. Variables used are added to the rules.
+func (r *Rules) context(in, out map[string]bool, vars map[string][]string) string {
+	best := ""
+	for k, vals := range vars {
+		set := make(map[string]bool)
+		for _, v := range vals {
+			set[v] = true
+		}
+		if !subset(in, set) || !disjoint(set, out) {
+			continue
+		}
+		if best == "" || len(vals) < len(vars[best]) || len(vals) == len(vars[best]) && k < best {
+			best = k
+		}
+	}
+	if best != "" {
+		r.Vars[best] = vars[best]
+		return best
+	}
+	var cs []string
+	for c := range in {
+		cs = append(cs, c)
+	}
+	sort.Strings(cs)
+	return "(" + strings.Join(cs, ", ") + ")"
+}
+
+// candidates returns variables that contexts can be expressed with: vars
+// without the ALL variable and wildcards and with END standing for the word
+// boundary unless vars declare it.
+func candidates(vars map[string][]string) map[string][]string {
+	out := map[string][]string{endVar: {"$"}}
+	for k, v := range vars {
+		if k == "ALL" || k == anyVar || strings.Join(v, "") == "*" {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// subset reports whether all keys of s1 are present in s2.
+func subset(s1, s2 map[string]bool) bool {
+	for k := range s1 {
+		if !s2[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// disjoint reports whether s1 and s2 have no keys in common.
+func disjoint(s1, s2 map[string]bool) bool {
+	for k := range s1 {
+		if s2[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package learn
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test if Induce proposes default rules for the most frequent pronunciation
+// and sets the other ones apart with contexts expressed with variables.
+func TestInduce(t *testing.T) {
+	pair := func(g string, p ...string) Pair { return Pair{g, p} }
+	als := []Alignment{
+		{pair("b", "b"), pair("a", "a"), pair("b", "p")},
+		{pair("a", "a"), pair("b", "b"), pair("a", "a")},
+		{pair("k", "k"), pair("a", "a"), pair("b", "p")},
+		{pair("b", "b"), pair("o", "o")},
+	}
+	vars := map[string][]string{"SA": {"a", "o"}, "ALL": {"x"}, "EMPTY": {"*"}}
+	have := Induce(als, Options{Vars: vars})
+	want := []Rule{
+		{"EMPTY", "a", "EMPTY", []string{"a"}, 4},
+		{"EMPTY", "b", "EMPTY", []string{"b"}, 3},
+		{"EMPTY", "b", "END", []string{"p"}, 2},
+		{"EMPTY", "k", "EMPTY", []string{"k"}, 1},
+		{"EMPTY", "o", "EMPTY", []string{"o"}, 1},
+	}
+	if ok := reflect.DeepEqual(have.Rules, want); !ok {
+		t.Errorf("have %v; want: %v", have.Rules, want)
+	}
+	if ok := reflect.DeepEqual(have.Alphabet, []string{"a", "b", "k", "o"}); !ok {
+		t.Errorf("have %v; want: [a b k o]", have.Alphabet)
+	}
+	if ok := reflect.DeepEqual(have.Vars, map[string][]string{"END": {"$"}}); !ok {
+		t.Errorf("have %v; want only END", have.Vars)
+	}
+}
+
+// Test if contexts use the smallest variable that sets the pronunciation
+// apart and fall back to the left context if the right one does not.
+func TestInduceContext(t *testing.T) {
+	pair := func(g string, p ...string) Pair { return Pair{g, p} }
+	als := []Alignment{
+		{pair("w", "f"), pair("k", "k")},
+		{pair("w", "f"), pair("t", "t")},
+		{pair("w", "w"), pair("a", "a")},
+		{pair("w", "w"), pair("o", "o")},
+		{pair("w", "w"), pair("e", "e")},
+		{pair("z", "s"), pair("t", "t")},
+		{pair("z", "s"), pair("a", "a")},
+		{pair("o", "o"), pair("z", "z"), pair("a", "a")},
+		{pair("o", "o"), pair("z", "z"), pair("a", "a")},
+		{pair("o", "o"), pair("z", "z"), pair("a", "a")},
+	}
+	vars := map[string][]string{
+		"SB":  {"k", "t", "p", "s"},
+		"SBK": {"k", "t", "p"},
+		"SA":  {"a", "o", "e"},
+	}
+	have := Induce(als, Options{Vars: vars})
+	var ctx []string
+	for _, r := range have.Rules {
+		if r.Source == "w" || r.Source == "z" {
+			ctx = append(ctx, r.Left+" "+r.Right)
+		}
+	}
+	want := []string{"EMPTY EMPTY", "EMPTY SBK", "EMPTY EMPTY", "END EMPTY"}
+	if ok := reflect.DeepEqual(ctx, want); !ok {
+		t.Errorf("have %v; want: %v", ctx, want)
+	}
+}
+
+// Test if contexts not covered by any variable are written as literal sets.
+func TestInduceLiteral(t *testing.T) {
+	pair := func(g string, p ...string) Pair { return Pair{g, p} }
+	als := []Alignment{
+		{pair("b", "p"), pair("k", "k")},
+		{pair("b", "p"), pair("t", "t")},
+		{pair("b", "b"), pair("a", "a")},
+		{pair("b", "b"), pair("o", "o")},
+		{pair("b", "b"), pair("e", "e")},
+	}
+	have := Induce(als, Options{})
+	want := Rule{"EMPTY", "b", "(k, t)", []string{"p"}, 2}
+	if len(have.Rules) < 3 || !reflect.DeepEqual(have.Rules[2], want) {
+		t.Errorf("have %v; want %v among rules", have.Rules, want)
+	}
+}
+
+// Test if Rule.String() formats the rule as a rule file line.
+func TestRuleString(t *testing.T) {
+	r := Rule{Left: "EMPTY", Source: "b", Right: "END", Targets: []string{"p", "b"}}
+	if have, want := r.String(), "EMPTY\tb\tEND\tp, b"; have != want {
+		t.Errorf("have %q; want: %q", have, want)
+	}
+}
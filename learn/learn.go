@@ -0,0 +1,182 @@
+/*
+Package learn induces grapheme-to-phoneme rules from a pronunciation lexicon.
+
+Graphemes of each word are aligned with its phonemes using expectation
+maximization over segmentations of the word into chunks of one to three
+graphemes and one or two phonemes. Rules in the four-column format of prg2p
+are then proposed for each grapheme chunk: the most frequent pronunciation
+becomes the default rule and the other ones get contexts telling them apart,
+expressed with variables whenever a variable covers the context exactly
+enough. The rule file output by Rules.Format can be loaded with prg2p.Load.
+
+	lex, err := learn.ReadLexicon(f)
+	rules, report, err := learn.Learn(lex, 0.1, learn.Options{Vars: vars})
+	fmt.Print(rules.Format())
+*/
+package learn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mdm-code/prg2p"
+)
+
+// Entry is a word of the pronunciation lexicon with its phonemes.
+type Entry struct {
+	Word     string
+	Phonemes []string
+}
+
+// Options configure alignment and rule induction. Zero values are replaced
+// with defaults.
+type Options struct {
+	MaxGraphemes int                 // Longest grapheme chunk, 3 by default.
+	MaxPhonemes  int                 // Longest phoneme chunk, 2 by default.
+	Iterations   int                 // Iterations of the alignment, 10 by default.
+	MinCount     int                 // Fewest occurrences to propose a rule, 2 by default.
+	Vars         map[string][]string // Variables to express contexts with.
+}
+
+// withDefaults returns options with zero values replaced with defaults.
+func (o Options) withDefaults() Options {
+	if o.MaxGraphemes <= 0 {
+		o.MaxGraphemes = 3
+	}
+	if o.MaxPhonemes <= 0 {
+		o.MaxPhonemes = 2
+	}
+	if o.Iterations <= 0 {
+		o.Iterations = 10
+	}
+	if o.MinCount <= 0 {
+		o.MinCount = 2
+	}
+	return o
+}
+
+// Report summarizes how the induced rules transcribe the held-out words.
+// Covered words are the ones transcribed without errors, Correct words have
+// the lexicon pronunciation as the first variant and Matched words have it
+// among all variants.
+type Report struct {
+	Train, Test int
+	Covered     int
+	Correct     int
+	Matched     int
+}
+
+// Coverage returns the share of held-out words transcribed without errors.
+func (r Report) Coverage() float64 {
+	return ratio(r.Covered, r.Test)
+}
+
+// Accuracy returns the share of held-out words with the lexicon
+// pronunciation as the first variant.
+func (r Report) Accuracy() float64 {
+	return ratio(r.Correct, r.Test)
+}
+
+// String returns the report in a human-readable form.
+func (r Report) String() string {
+	return fmt.Sprintf(
+		"train %d, test %d, coverage %.1f%%, accuracy %.1f%%, any variant %.1f%%",
+		r.Train, r.Test, 100*r.Coverage(), 100*r.Accuracy(), 100*ratio(r.Matched, r.Test),
+	)
+}
+
+// ReadLexicon reads a lexicon with one word per line followed by a tab and
+// space-separated phonemes. Words are lowercased and empty lines are
+// skipped.
+func ReadLexicon(r io.Reader) ([]Entry, error) {
+	var out []Entry
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		l := strings.TrimSpace(s.Text())
+		if l == "" {
+			continue
+		}
+		word, trans, ok := strings.Cut(l, "\t")
+		ph := strings.Fields(trans)
+		if !ok || len(ph) == 0 {
+			return nil, fmt.Errorf("expected word and phonemes on line %d", n)
+		}
+		out = append(out, Entry{Word: strings.ToLower(strings.TrimSpace(word)), Phonemes: ph})
+	}
+	return out, s.Err()
+}
+
+// Learn induces rules from the lexicon lex holding out the share holdout of
+// entries, every n-th entry, to evaluate the rules on.
+func Learn(lex []Entry, holdout float64, o Options) (*Rules, Report, error) {
+	if holdout < 0 || holdout >= 1 {
+		return nil, Report{}, fmt.Errorf("held-out share %v out of range [0, 1)", holdout)
+	}
+	train, test := split(lex, holdout)
+	if len(train) == 0 {
+		return nil, Report{}, fmt.Errorf("no entries to learn from")
+	}
+	rules := Induce(Align(train, o), o)
+	report, err := Evaluate(rules, test)
+	if err != nil {
+		return nil, Report{}, err
+	}
+	report.Train = len(train)
+	return rules, report, nil
+}
+
+// Evaluate transcribes words of the lexicon lex with the rules and reports
+// how many of them were transcribed as in the lexicon.
+func Evaluate(rules *Rules, lex []Entry) (Report, error) {
+	g2p, err := prg2p.Load(strings.NewReader(rules.Format()))
+	if err != nil {
+		return Report{}, err
+	}
+	r := Report{Test: len(lex)}
+	for _, e := range lex {
+		trans, err := g2p.Transcribe(e.Word, true)
+		if err != nil {
+			continue
+		}
+		r.Covered++
+		want := strings.Join(e.Phonemes, " ")
+		if trans[0] == want {
+			r.Correct++
+		}
+		for _, t := range trans {
+			if t == want {
+				r.Matched++
+				break
+			}
+		}
+	}
+	return r, nil
+}
+
+// split divides the lexicon lex into entries to learn from and every n-th
+// entry held out for evaluation, where n follows from the share holdout.
+func split(lex []Entry, holdout float64) ([]Entry, []Entry) {
+	if holdout == 0 {
+		return lex, nil
+	}
+	n := int(1/holdout + 0.5)
+	var train, test []Entry
+	for i, e := range lex {
+		if (i+1)%n == 0 {
+			test = append(test, e)
+			continue
+		}
+		train = append(train, e)
+	}
+	return train, test
+}
+
+// ratio returns a/b or 0 if b is 0.
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
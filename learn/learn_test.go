@@ -0,0 +1,132 @@
+package learn
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mdm-code/prg2p"
+)
+
+// Words transcribed with the default rules to make up a lexicon for testing.
+var words = strings.Fields(`
+ala ma kota kot pies dom szkoła dziecko rzeka krzyk przy także zima ciemno
+wieś mówię chleb woda noga ręka głowa oko ucho nos ząb brzeg chata czas cień
+dzień noc rok lato jesień wiosna żaba żółw ryba ptak koń krowa świnia kura
+kaczka gęś mleko masło ser jajko zupa mięso sól cukier kawa herbata sok piwo
+wino miasto ulica droga most jezioro morze góra las pole łąka drzewo kwiat
+trawa liść słońce księżyc gwiazda niebo chmura deszcz śnieg wiatr burza zimno
+ciepło gorąco mokro sucho dobry zły duży mały nowy stary młody piękny brzydki
+szybki wolny wysoki niski długi krótki gruby chudy biały czarny czerwony
+zielony niebieski żółty szary brązowy różowy`)
+
+// Fresh lexicon of words transcribed with the default rules.
+func lexicon(t *testing.T) ([]Entry, map[string][]string) {
+	t.Helper()
+	g2p, err := prg2p.Load(prg2p.Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	var lex []Entry
+	for _, w := range words {
+		trans, err := g2p.Transcribe(w, false)
+		if err != nil {
+			t.Fatalf("failed to transcribe: %s", w)
+		}
+		lex = append(lex, Entry{Word: w, Phonemes: strings.Fields(trans[0])})
+	}
+	return lex, g2p.Vars()
+}
+
+// Test if ReadLexicon reads words with their phonemes.
+func TestReadLexicon(t *testing.T) {
+	in := "Ala\ta l a\n\nkot\tk  o t \n"
+	want := []Entry{
+		{Word: "ala", Phonemes: []string{"a", "l", "a"}},
+		{Word: "kot", Phonemes: []string{"k", "o", "t"}},
+	}
+	have, err := ReadLexicon(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("failed to read lexicon: %s", err)
+	}
+	if ok := reflect.DeepEqual(have, want); !ok {
+		t.Errorf("have %v; want: %v", have, want)
+	}
+}
+
+// Test if ReadLexicon errors out on lines without phonemes.
+func TestReadLexiconFails(t *testing.T) {
+	for _, in := range []string{"ala", "ala\t", "ala a l a"} {
+		if _, err := ReadLexicon(strings.NewReader(in)); err == nil {
+			t.Errorf("line %q should cause an error", in)
+		}
+	}
+}
+
+// Test if the rules learned from the lexicon load with prg2p.Load and
+// transcribe most of the held-out words.
+func TestLearn(t *testing.T) {
+	lex, vars := lexicon(t)
+	rules, report, err := Learn(lex, 0.1, Options{Vars: vars})
+	if err != nil {
+		t.Fatalf("failed to learn rules: %s", err)
+	}
+	if report.Train+report.Test != len(lex) || report.Test != len(lex)/10 {
+		t.Errorf("have %d/%d split; want %d held out", report.Train, report.Test, len(lex)/10)
+	}
+	if report.Coverage() < 0.9 {
+		t.Errorf("have coverage %.2f; want at least 0.9", report.Coverage())
+	}
+	if report.Accuracy() < 0.5 {
+		t.Errorf("have accuracy %.2f; want at least 0.5", report.Accuracy())
+	}
+	g2p, err := prg2p.Load(strings.NewReader(rules.Format()))
+	if err != nil {
+		t.Fatalf("failed to load learned rules: %s", err)
+	}
+	for _, w := range []string{"ala", "kot", "szkoła"} {
+		if _, err := g2p.Transcribe(w, false); err != nil {
+			t.Errorf("failed to transcribe %s with learned rules", w)
+		}
+	}
+}
+
+// Test if Learn errors out on held-out shares out of range and on empty
+// lexicons.
+func TestLearnFails(t *testing.T) {
+	lex, _ := lexicon(t)
+	for _, h := range []float64{-0.1, 1, 2} {
+		if _, _, err := Learn(lex, h, Options{}); err == nil {
+			t.Errorf("held-out share %v should cause an error", h)
+		}
+	}
+	if _, _, err := Learn(nil, 0, Options{}); err == nil {
+		t.Error("empty lexicon should cause an error")
+	}
+}
+
+// Test if Evaluate counts words transcribed as in the lexicon.
+func TestEvaluate(t *testing.T) {
+	rules := &Rules{
+		Alphabet: []string{"a", "k", "o", "t"},
+		Rules: []Rule{
+			{Left: "EMPTY", Source: "a", Right: "EMPTY", Targets: []string{"a"}},
+			{Left: "EMPTY", Source: "k", Right: "EMPTY", Targets: []string{"g", "k"}},
+			{Left: "EMPTY", Source: "o", Right: "EMPTY", Targets: []string{"o"}},
+			{Left: "EMPTY", Source: "t", Right: "EMPTY", Targets: []string{"t"}},
+		},
+	}
+	lex := []Entry{
+		{Word: "kota", Phonemes: []string{"k", "o", "t", "a"}},
+		{Word: "gat", Phonemes: []string{"g", "a", "t"}},
+		{Word: "tak", Phonemes: []string{"t", "a", "g"}},
+	}
+	have, err := Evaluate(rules, lex)
+	if err != nil {
+		t.Fatalf("failed to evaluate rules: %s", err)
+	}
+	want := Report{Test: 3, Covered: 2, Correct: 1, Matched: 2}
+	if have != want {
+		t.Errorf("have %+v; want: %+v", have, want)
+	}
+}
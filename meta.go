@@ -0,0 +1,135 @@
+package prg2p
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the package checked against the engine version
+// required by rule files with the @requires directive.
+const Version = "1.0.0"
+
+// headerKeys are metadata keys of the rule file that have directives of
+// their own, in the order they are written.
+var headerKeys = []string{"name", "version", "author", "language", "phoneset", "requires"}
+
+// Meta describes the rule file that G2P was loaded from so that transcripts
+// can be traced back to the exact version of the rules. Fields are set with
+// the header directives of the rule file and Extra holds the other metadata
+// set with the @meta directive. Metadata of included files is ignored.
+//
+// Example:
+//
+//	@name standard
+//	@version 1.2.0
+//	@author Jan Kowalski
+//	@language pl
+//	@phoneset prg2p
+//	@requires 1.0.0
+type Meta struct {
+	Name     string            `json:"name,omitempty"`
+	Version  string            `json:"version,omitempty"`
+	Author   string            `json:"author,omitempty"`
+	Language string            `json:"language,omitempty"`
+	PhoneSet string            `json:"phoneset,omitempty"`
+	Requires string            `json:"requires,omitempty"`
+	Extra    map[string]string `json:"extra,omitempty"`
+}
+
+// Meta returns the metadata of the rule file.
+func (g *G2P) Meta() Meta {
+	if g.interp == nil {
+		return Meta{}
+	}
+	return newMeta(g.interp.meta)
+}
+
+// newMeta returns Meta with the header fields taken from the metadata kv and
+// the remaining keys in Extra.
+func newMeta(kv map[string]string) Meta {
+	m := Meta{
+		Name:     kv["name"],
+		Version:  kv["version"],
+		Author:   kv["author"],
+		Language: kv["language"],
+		PhoneSet: kv["phoneset"],
+		Requires: kv["requires"],
+	}
+	for k, v := range kv {
+		if isHeaderKey(k) {
+			continue
+		}
+		if m.Extra == nil {
+			m.Extra = make(map[string]string)
+		}
+		m.Extra[k] = v
+	}
+	return m
+}
+
+// isHeaderKey reports whether the metadata key k has a directive of its own.
+func isHeaderKey(k string) bool {
+	for _, h := range headerKeys {
+		if h == k {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHeader parses the header directive name with the value arg and
+// returns the metadata key it sets. The value of @requires must be a
+// version.
+func parseHeader(name, arg string) (string, error) {
+	key := strings.TrimPrefix(name, "@")
+	if arg == "" {
+		return "", fmt.Errorf("expected value")
+	}
+	if key == "requires" {
+		if _, err := parseVersion(arg); err != nil {
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+// checkRequires returns an error if the engine version req required by the
+// rules is later than Version.
+func checkRequires(req string) error {
+	if req == "" {
+		return nil
+	}
+	want, err := parseVersion(req)
+	if err != nil {
+		return err
+	}
+	have, _ := parseVersion(Version)
+	for k := range want {
+		if have[k] != want[k] {
+			if have[k] < want[k] {
+				return fmt.Errorf("rules require prg2p %s or later, have %s", req, Version)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// parseVersion returns the major, minor and patch numbers of the version v
+// written as MAJOR[.MINOR[.PATCH]] with an optional "v" prefix.
+func parseVersion(v string) ([3]int, error) {
+	var nums [3]int
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) > len(nums) {
+		return nums, fmt.Errorf("invalid version %s", v)
+	}
+	for k, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nums, fmt.Errorf("invalid version %s", v)
+		}
+		nums[k] = n
+	}
+	return nums, nil
+}
@@ -0,0 +1,127 @@
+package prg2p
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// Rules with the metadata header for testing Meta.
+const metaRules = `@name test
+@version 1.2.0
+@author Jan Kowalski
+@language pl
+@phoneset prg2p
+@requires 1.0
+@meta dialect standard
+ALL = a, b, $
+EMPTY = *
+EMPTY	a	EMPTY	a
+EMPTY	b	EMPTY	b
+`
+
+// Test if header directives of rule files are exposed with G2P.Meta.
+func TestMeta(t *testing.T) {
+	g2p, err := Load(strings.NewReader(metaRules))
+	if err != nil {
+		t.Fatalf("failed to load rules: %s", err)
+	}
+	want := Meta{
+		Name:     "test",
+		Version:  "1.2.0",
+		Author:   "Jan Kowalski",
+		Language: "pl",
+		PhoneSet: "prg2p",
+		Requires: "1.0",
+		Extra:    map[string]string{"dialect": "standard"},
+	}
+	if have := g2p.Meta(); !reflect.DeepEqual(have, want) {
+		t.Errorf("have %+v; want: %+v", have, want)
+	}
+	if have := new(G2P).Meta(); !reflect.DeepEqual(have, Meta{}) {
+		t.Errorf("have %+v; want empty metadata", have)
+	}
+}
+
+// Test if metadata survives the conversion to RuleSet and back.
+func TestMetaRuleSet(t *testing.T) {
+	rs, err := ReadRuleSet(strings.NewReader(metaRules))
+	if err != nil {
+		t.Fatalf("failed to read rule set: %s", err)
+	}
+	if rs.Meta["version"] != "1.2.0" || rs.Meta["dialect"] != "standard" {
+		t.Errorf("have %v; want version and dialect", rs.Meta)
+	}
+	var buf bytes.Buffer
+	if _, err := rs.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "@name test\n@version 1.2.0\n") {
+		t.Errorf("have %q; want header directives first", buf.String())
+	}
+	g2p, err := LoadRuleSet(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := g2p.Meta().Name; have != "test" {
+		t.Errorf("have %s; want: test", have)
+	}
+}
+
+// Test if rules requiring a later version of the package or with malformed
+// header directives fail to load.
+func TestMetaFails(t *testing.T) {
+	cases := []string{
+		"@requires 99.0.0",
+		"@requires v1.99",
+		"@requires 1.x",
+		"@requires",
+		"@name",
+	}
+	for _, c := range cases {
+		t.Run(c, func(t *testing.T) {
+			r := strings.Replace(metaRules, "@requires 1.0", c, 1)
+			if _, err := Load(strings.NewReader(r)); err == nil {
+				t.Error("expected rules to fail to load")
+			}
+		})
+	}
+}
+
+// Test if metadata of included files does not replace that of the including
+// file while their @requires is still checked.
+func TestMetaInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"parent.txt": {Data: []byte("@name parent\n@requires 1.0.0\n#include \"child.txt\"\n")},
+		"child.txt":  {Data: []byte("@name child\n@version 2.0.0\n@requires 0.1\n" + metaRules[strings.Index(metaRules, "ALL"):])},
+		"newer.txt":  {Data: []byte("@name newer\n#include \"later.txt\"\n")},
+		"later.txt":  {Data: []byte("@requires 99.0.0\n")},
+	}
+	g2p, err := LoadFS(fsys, "parent.txt")
+	if err != nil {
+		t.Fatalf("failed to load rules: %s", err)
+	}
+	want := Meta{Name: "parent", Requires: "1.0.0"}
+	if have := g2p.Meta(); !reflect.DeepEqual(have, want) {
+		t.Errorf("have %+v; want: %+v", have, want)
+	}
+	if _, err := LoadFS(fsys, "newer.txt"); err == nil {
+		t.Error("expected rules including a file that requires a later version to fail")
+	}
+}
+
+// Test if shipped rule sets are identified by their metadata.
+func TestMetaNamed(t *testing.T) {
+	for _, info := range RuleSets() {
+		g2p, err := LoadNamed(info.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := g2p.Meta()
+		if m.Name != info.Name || m.Version == "" || m.Language != "pl" {
+			t.Errorf("have %+v; want metadata of %s", m, info.Name)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package prg2p
+
+import "io"
+
+// Option configures G2P when rules are loaded.
+type Option func(*G2P)
+
+// Matcher selects the strategy used to pick the rule that transcribes the
+// part of the word starting at a given position.
+type Matcher int
+
+const (
+	// MatchSpecific considers all rules matching the word at a position and
+	// picks the most specific one: the one with the highest priority, then
+	// the longest source, then the longest matched context and, finally, the
+	// one listing the fewest alternative contexts.
+	MatchSpecific Matcher = iota
+
+	// MatchFirst walks the right and then left context depth-first and picks
+	// the first rule found. It is the behaviour of earlier versions of the
+	// package where a generic rule may win over a narrower context depending
+	// on the shape of the tree.
+	MatchFirst
+)
+
+// WithMatcher sets the strategy used to pick rules. MatchSpecific is used by
+// default.
+func WithMatcher(m Matcher) Option {
+	return func(g *G2P) {
+		g.matcher = m
+	}
+}
+
+// WithCoverage enables counting how many times each rule is used during
+// transcription. Counts are reported by G2P.Coverage.
+func WithCoverage() Option {
+	return func(g *G2P) {
+		g.cov = newTracker()
+	}
+}
+
+// Normalization is the Unicode normalization form words are converted to
+// before they are transcribed.
+type Normalization int
+
+const (
+	// NoNormalization leaves words as they are.
+	NoNormalization Normalization = iota
+
+	// NFC composes characters, so that "o" followed by the combining acute
+	// accent is transcribed like "ó".
+	NFC
+
+	// NFD decomposes characters. It is only useful with rules written in the
+	// decomposed form.
+	NFD
+
+	// NFKC composes characters like NFC and replaces compatibility
+	// characters, such as the "ﬁ" ligature or fullwidth letters, with their
+	// canonical equivalents.
+	NFKC
+)
+
+// Unknown is the policy applied to characters of a word that no rule
+// transcribes.
+type Unknown int
+
+const (
+	// UnknownFail fails to transcribe the word.
+	UnknownFail Unknown = iota
+
+	// UnknownSkip drops the character from the transcript.
+	UnknownSkip
+
+	// UnknownKeep copies the character to the transcript as a phoneme.
+	UnknownKeep
+)
+
+// WithCaseFolding sets whether words are lower-cased before they are
+// transcribed. Words are lower-cased by default.
+func WithCaseFolding(fold bool) Option {
+	return func(g *G2P) {
+		g.fold = fold
+	}
+}
+
+// WithNormalization sets the Unicode normalization form words are converted
+// to before they are transcribed. NFC is used by default.
+func WithNormalization(n Normalization) Option {
+	return func(g *G2P) {
+		g.norm = n
+	}
+}
+
+// WithUnknown sets the policy for characters that no rule transcribes.
+// UnknownFail is used by default.
+func WithUnknown(u Unknown) Option {
+	return func(g *G2P) {
+		g.unknown = u
+	}
+}
+
+// WithMaxVariants limits the number of variants returned for a word to n,
+// keeping the ones that come first. Zero means no limit.
+func WithMaxVariants(n int) Option {
+	return func(g *G2P) {
+		g.limit = n
+	}
+}
+
+// WithCache keeps transcripts of up to size most recently transcribed words
+// so that repeated words are not transcribed again. The cache is not used
+// with WithCoverage or WithTrace, so that every word is counted and traced.
+func WithCache(size int) Option {
+	return func(g *G2P) {
+		g.cache = newCache(size)
+	}
+}
+
+// WithPhoneSet declares the phonemes that rules may output. Load fails if a
+// rule outputs any other phoneme.
+func WithPhoneSet(phones ...string) Option {
+	return func(g *G2P) {
+		g.phones = make(map[string]bool)
+		for _, p := range phones {
+			g.phones[p] = true
+		}
+	}
+}
+
+// WithLexicon sets the lexicon of exceptional words that Transcribe, Align
+// and Derive look up before they transcribe them with rules. Words of the
+// lexicon are prepared like words transcribed with rules, that is normalized,
+// case-folded and mapped as set with the other options.
+func WithLexicon(lex *Lexicon) Option {
+	return func(g *G2P) {
+		g.lexicon = lex
+	}
+}
+
+// WithTrace writes the segments of each transcribed word and the rules that
+// transcribed them to w, one tab-separated line per segment.
+func WithTrace(w io.Writer) Option {
+	return func(g *G2P) {
+		g.trace = w
+	}
+}
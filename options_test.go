@@ -0,0 +1,147 @@
+package prg2p
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// optionRules are rules used to test options of Load.
+const optionRules = `
+ALL = a, b, k, o, ó, t
+EMPTY = *
+EMPTY	a	EMPTY	a
+EMPTY	b	EMPTY	b, p
+EMPTY	k	EMPTY	k, g
+EMPTY	o	EMPTY	o
+EMPTY	ó	EMPTY	u
+EMPTY	t	EMPTY	t
+`
+
+// Test if options change how words are prepared and transcribed.
+func TestOptions(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		word string
+		want []string
+	}{
+		{"default", nil, "KOT", []string{"k o t", "g o t"}},
+		{"default nfc", nil, "kto\u0301t", []string{"k t u t", "g t u t"}},
+		{"no case folding", []Option{WithCaseFolding(false)}, "kot", []string{"k o t", "g o t"}},
+		{"nfkc", []Option{WithNormalization(NFKC)}, "\uff4bo\uff54", []string{"k o t", "g o t"}},
+		{"skip", []Option{WithUnknown(UnknownSkip)}, "kxot", []string{"k o t", "g o t"}},
+		{"keep", []Option{WithUnknown(UnknownKeep)}, "kxot", []string{"k x o t", "g x o t"}},
+		{"limit", []Option{WithMaxVariants(3)}, "bkb", []string{"b k b", "b k p", "b g b"}},
+		{"limit one", []Option{WithMaxVariants(1)}, "bk", []string{"b k"}},
+		{"lexicon", []Option{WithLexicon(NewLexicon(map[string][]string{"Kot": {"k o t"}}))}, "KOT", []string{"k o t"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g, err := Load(strings.NewReader(optionRules), c.opts...)
+			if err != nil {
+				t.Fatalf("failed to create G2P transcriber: %s", err)
+			}
+			have, err := g.Transcribe(c.word, true)
+			if err != nil {
+				t.Fatalf("failed to transcribe %s: %s", c.word, err)
+			}
+			if ok := reflect.DeepEqual(have, c.want); !ok {
+				t.Errorf("have %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Test if options make words fail to transcribe.
+func TestOptionsFail(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		word string
+	}{
+		{"no case folding", []Option{WithCaseFolding(false)}, "KOT"},
+		{"no normalization", []Option{WithNormalization(NoNormalization)}, "kto\u0301t"},
+		{"nfd", []Option{WithNormalization(NFD)}, "kt\u00f3t"},
+		{"nfc", nil, "\uff4bo\uff54"},
+		{"unknown", nil, "kxot"},
+		{"skip all", []Option{WithUnknown(UnknownSkip)}, "xx"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g, err := Load(strings.NewReader(optionRules), c.opts...)
+			if err != nil {
+				t.Fatalf("failed to create G2P transcriber: %s", err)
+			}
+			if _, err := g.Transcribe(c.word, true); err == nil {
+				t.Errorf("%s should fail to transcribe", c.word)
+			}
+		})
+	}
+}
+
+// Test if rules outputting phonemes outside of the phone set fail to load.
+func TestWithPhoneSet(t *testing.T) {
+	if _, err := Load(strings.NewReader(optionRules), WithPhoneSet("a", "b", "p", "k", "g", "o", "u", "t")); err != nil {
+		t.Errorf("failed to create G2P transcriber: %s", err)
+	}
+	_, err := Load(strings.NewReader(optionRules), WithPhoneSet("a", "b", "p", "k", "g", "o", "t"))
+	if err == nil {
+		t.Fatal("phoneme outside of the phone set should cause an error")
+	}
+	g, err := Load(strings.NewReader(optionRules), WithPhoneSet("a", "b", "p", "k", "g", "o", "u", "t"))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	if err := g.Eval("EMPTY\tk\t(o)\tx"); err == nil {
+		t.Error("phoneme outside of the phone set should cause an error")
+	}
+	if have, _ := g.Transcribe("ko", false); !reflect.DeepEqual(have, []string{"k o"}) {
+		t.Errorf("rule rejected by Eval should not be used: have %v", have)
+	}
+}
+
+// Test if the cache serves repeated words and is cleared when rules change.
+func TestWithCache(t *testing.T) {
+	g, err := Load(strings.NewReader(optionRules), WithCache(1))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	for _, w := range []string{"kot", "kot", "bok", "kot"} {
+		if _, err := g.Transcribe(w, true); err != nil {
+			t.Fatalf("failed to transcribe %s: %s", w, err)
+		}
+	}
+	have, _ := g.Transcribe("kot", true)
+	have[0] = "changed"
+	have, _ = g.Transcribe("kot", true)
+	if ok := reflect.DeepEqual(have, []string{"k o t", "g o t"}); !ok {
+		t.Errorf("cached transcripts should not change: have %v", have)
+	}
+	if err := g.Eval("EMPTY\tk\tEMPTY\tk"); err != nil {
+		t.Fatalf("failed to evaluate rule: %s", err)
+	}
+	have, _ = g.Transcribe("kot", true)
+	if ok := reflect.DeepEqual(have, []string{"k o t"}); !ok {
+		t.Errorf("have %v; want: [k o t]", have)
+	}
+}
+
+// Test if the trace lists segments of transcribed words with their rules.
+func TestWithTrace(t *testing.T) {
+	var b strings.Builder
+	lex := NewLexicon(map[string][]string{"ok": {"o k"}})
+	g, err := Load(strings.NewReader(optionRules), WithTrace(&b), WithLexicon(lex), WithUnknown(UnknownKeep))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	for _, w := range []string{"bx", "ok"} {
+		if _, err := g.Transcribe(w, false); err != nil {
+			t.Fatalf("failed to transcribe %s: %s", w, err)
+		}
+	}
+	want := "bx\tb\tb|p\tline 5\nbx\tx\tx\t-\nok\tok\to k\tlexicon\n"
+	if have := b.String(); have != want {
+		t.Errorf("have %q; want: %q", have, want)
+	}
+}
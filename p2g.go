@@ -0,0 +1,204 @@
+package prg2p
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// beamWidth is the number of partial spellings kept for each position of
+// the phonemic transcript during the search.
+const beamWidth = 256
+
+// P2G phoneme-to-grapheme converter built from the same rules as G2P. It
+// indexes grapheme-to-phoneme rules by their targets and offers spellings
+// that G2P transcribes back to the given phonemes.
+type P2G struct {
+	g2p   *G2P
+	index map[string][]arc
+}
+
+// arc is a way of spelling the phonemes of a single rule target. The weight
+// is the share of rules offering the phonemes that spell them with the source
+// of the arc.
+type arc struct {
+	phonemes []string
+	source   string
+	weight   float64
+	rules    []*rule
+}
+
+// hyp is a partial spelling of the phonemic transcript covering its first
+// pos phonemes.
+type hyp struct {
+	spelling string
+	pos      int
+	score    float64
+}
+
+// NewP2G returns P2G for rules loaded into g. Rules added to g with Eval
+// later on are not taken into account. Phonological rules are not inverted
+// so spellings are checked against transcripts output by the
+// grapheme-to-phoneme rules alone.
+func NewP2G(g *G2P) (*P2G, error) {
+	if g == nil || g.interp == nil {
+		return nil, ErrNotLoaded
+	}
+	p := P2G{g2p: g, index: make(map[string][]arc)}
+	type key struct{ phonemes, source string }
+	arcs := make(map[key]*arc)
+	totals := make(map[string]int)
+	var order []key
+	for k := range g.interp.rules {
+		r := &g.interp.rules[k]
+		for _, t := range r.target {
+			ph := strings.Fields(t)
+			if len(ph) == 0 {
+				continue
+			}
+			k := key{strings.Join(ph, " "), r.source}
+			a, ok := arcs[k]
+			if !ok {
+				a = &arc{phonemes: ph, source: r.source}
+				arcs[k] = a
+				order = append(order, k)
+			}
+			a.rules = append(a.rules, r)
+			totals[k.phonemes]++
+		}
+	}
+	for _, k := range order {
+		a := arcs[k]
+		a.weight = float64(len(a.rules)) / float64(totals[k.phonemes])
+		p.index[a.phonemes[0]] = append(p.index[a.phonemes[0]], *a)
+	}
+	return &p, nil
+}
+
+// LoadP2G returns a fully initialized P2G object with rules read from r and
+// configured with opts.
+func LoadP2G(r io.Reader, opts ...Option) (*P2G, error) {
+	g, err := Load(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewP2G(g)
+}
+
+// Transcribe phonemic transcript t with space-separated phonemes to spelling.
+// Spellings are ranked from the most to the least likely one. Use all to
+// specify whether to return all spellings or just the first one.
+func (p *P2G) Transcribe(t string, all bool) ([]string, error) {
+	ph := strings.Fields(t)
+	if len(ph) == 0 {
+		return []string{}, fmt.Errorf("empty phonemic transcript: %w", ErrEmptyInput)
+	}
+	var out []string
+	for _, h := range p.search(ph) {
+		if p.roundTrip(h.spelling, strings.Join(ph, " ")) {
+			out = append(out, h.spelling)
+		}
+	}
+	if len(out) == 0 {
+		return []string{}, fmt.Errorf("failed to spell %s", t)
+	}
+	if all {
+		return out, nil
+	}
+	return out[:1], nil
+}
+
+// search runs the beam search over the phonemes ph and returns complete
+// spellings ranked by their scores.
+func (p *P2G) search(ph []string) []hyp {
+	beams := make([][]hyp, len(ph)+1)
+	beams[0] = []hyp{{}}
+	for pos := 0; pos < len(ph); pos++ {
+		beams[pos] = prune(beams[pos])
+		for _, h := range beams[pos] {
+			for _, a := range p.index[ph[pos]] {
+				if !a.follows(h.spelling) || !hasPrefix(ph[pos:], a.phonemes) {
+					continue
+				}
+				next := hyp{
+					spelling: h.spelling + a.source,
+					pos:      pos + len(a.phonemes),
+					score:    h.score + math.Log(a.weight),
+				}
+				beams[next.pos] = append(beams[next.pos], next)
+			}
+		}
+	}
+	return prune(beams[len(ph)])
+}
+
+// roundTrip reports whether the spelling s is transcribed to t by the
+// grapheme-to-phoneme rules.
+func (p *P2G) roundTrip(s, t string) bool {
+	vs, err := p.g2p.variants(s)
+	if err != nil {
+		return false
+	}
+	return contains(vs, t)
+}
+
+// follows reports whether any of the rules of the arc a can apply after the
+// spelling s considering their left context.
+func (a *arc) follows(s string) bool {
+	for _, r := range a.rules {
+		if r.left == nil {
+			return true
+		}
+		for _, c := range r.left {
+			if strings.HasPrefix(c, "$") && s == c[1:] {
+				return true
+			}
+			if !strings.Contains(c, "$") && strings.HasSuffix(s, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// prune merges hypotheses with the same spelling keeping the best score and
+// returns at most beamWidth best hypotheses ordered by score and spelling.
+func prune(hs []hyp) []hyp {
+	best := make(map[string]int)
+	var out []hyp
+	for _, h := range hs {
+		if k, ok := best[h.spelling]; ok {
+			if h.score > out[k].score {
+				out[k] = h
+			}
+			continue
+		}
+		best[h.spelling] = len(out)
+		out = append(out, h)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].score != out[j].score {
+			return out[i].score > out[j].score
+		}
+		return out[i].spelling < out[j].spelling
+	})
+	if len(out) > beamWidth {
+		out = out[:beamWidth]
+	}
+	return out
+}
+
+// hasPrefix reports whether the phonemes s start with the phonemes prefix.
+func hasPrefix(s, prefix []string) bool {
+	if len(prefix) > len(s) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package prg2p
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test P2G.Transcribe() returning the most likely spelling first.
+func TestP2GTranscribe(t *testing.T) {
+	cases := []struct {
+		name  string
+		trans string
+		all   bool
+		want  []string
+	}{
+		{"ala", "a l a", false, []string{"ala"}},
+		{"kota", "k o t a", false, []string{"kota"}},
+		{"zima", "zi i m a", false, []string{"zima"}},
+		{"przy", "p sz y", false, []string{"przy"}},
+		{"chleb", "h l e p", false, []string{"chleb"}},
+		{"kota-all", "k o t a", true, []string{"kota", "qota"}},
+		{"spaces", "  a   l a ", false, []string{"ala"}},
+	}
+	p2g, err := LoadP2G(rulesIO())
+	if err != nil {
+		t.Fatal("failed to create P2G converter")
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			have, err := p2g.Transcribe(c.trans, c.all)
+			if err != nil {
+				t.Fatalf("failed to spell: %s", c.trans)
+			}
+			if ok := reflect.DeepEqual(have, c.want); !ok {
+				t.Errorf("have %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Test if P2G.Transcribe() errors out on transcripts it cannot spell.
+func TestP2GTranscribeFails(t *testing.T) {
+	p2g, err := LoadP2G(rulesIO())
+	if err != nil {
+		t.Fatal("failed to create P2G converter")
+	}
+	for _, trans := range []string{"", "  ", "x y z", "a l a_ ?"} {
+		if _, err := p2g.Transcribe(trans, true); err == nil {
+			t.Errorf("transcript %q should cause an error", trans)
+		}
+	}
+}
+
+// Test if NewP2G errors out on G2P without rules.
+func TestNewP2GFails(t *testing.T) {
+	if _, err := NewP2G(newG2P(nil)); err == nil {
+		t.Error("G2P without rules should cause an error")
+	}
+}
+
+// Test if every spelling offered for a transcript is transcribed back to it
+// and if the original word is among the spellings of each of its
+// transcripts.
+func TestP2GRoundTrip(t *testing.T) {
+	words := []string{
+		"ala", "ma", "kota", "przy", "krzyk", "chleb", "zima", "rzeka",
+		"mówię", "szkoła", "dziecko", "także", "ciemno", "wieś",
+	}
+	g2p, err := Load(rulesIO())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	p2g, err := NewP2G(g2p)
+	if err != nil {
+		t.Fatal("failed to create P2G converter")
+	}
+	for _, w := range words {
+		t.Run(w, func(t *testing.T) {
+			trans, err := g2p.Transcribe(w, true)
+			if err != nil {
+				t.Fatalf("failed to transcribe: %s", w)
+			}
+			for _, x := range trans {
+				spellings, err := p2g.Transcribe(x, true)
+				if err != nil {
+					t.Fatalf("failed to spell: %s", x)
+				}
+				if !contains(spellings, w) {
+					t.Errorf("have %v; want %s among spellings of %s", spellings, w, x)
+				}
+				for _, s := range spellings {
+					back, err := g2p.Transcribe(s, true)
+					if err != nil || !contains(back, x) {
+						t.Errorf("spelling %s of %s transcribes to %v", s, x, back)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package prg2p
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Section headers of the rule file. Grapheme-to-phoneme rules are expected in
+// the rules section, which is the default, while phonological rules are
+// expected in the phonology section. Variables can be declared in both.
+const (
+	rulesSection     = "[RULES]"
+	phonologySection = "[PHONOLOGY]"
+)
+
+// boundary stands for the beginning or the end of the word in contexts of
+// phonological rules.
+const boundary = "#"
+
+// deletion is the right-hand side of phonological rules deleting phonemes.
+const deletion = "0"
+
+// phonRule is an ordered phoneme-level rewrite rule in the format
+// A -> B / L _ R, where A, L and R are sequences of phoneme sets and B is a
+// sequence of phonemes that replaces A if preceded by L and followed by R.
+// When a set of B has as many phonemes as the corresponding set of A, each
+// phoneme of A is replaced with the phoneme of B at the same position. The
+// environment is optional, and B is "0" for rules deleting A.
+//
+// Examples:
+// e_ -> e n / _ (t, d)
+// (b, d, g) -> (p, t, k) / _ #
+// VOICED -> VOICELESS / VOICELESS _
+type phonRule struct {
+	target      [][]string
+	change      [][]string
+	left, right [][]string
+	file        string
+	line        int
+	text        string
+}
+
+// Step records a single application of a phonological rule to a phonemic
+// transcript with the transcript before and after the rule was applied.
+type Step struct {
+	Rule          string
+	File          string
+	Line          int
+	Before, After string
+}
+
+// Derivation shows how the phonemic transcript output by grapheme-to-phoneme
+// rules was changed by the ordered phonological rules.
+type Derivation struct {
+	Input  string
+	Output string
+	Steps  []Step
+}
+
+// section evaluates a section header line.
+func (i *interpreter) section(l string) error {
+	switch l {
+	case rulesSection, phonologySection:
+		i.sect = l
+		return nil
+	}
+	return fmt.Errorf("unknown section %s", l)
+}
+
+// asPhonRule evaluates a line as a phonological rule.
+func (i *interpreter) asPhonRule(l string) error {
+	lhs, rhs, ok := strings.Cut(l, "->")
+	if !ok {
+		return fmt.Errorf("expected \"->\" in line %s", l)
+	}
+	chg, env, hasEnv := strings.Cut(rhs, "/")
+	target, err := i.bareSequence(strings.TrimSpace(lhs))
+	if err != nil {
+		return err
+	}
+	r := phonRule{
+		target: target,
+		file:   i.file,
+		line:   i.line,
+		text:   l,
+	}
+	if chg = strings.TrimSpace(chg); chg != deletion && chg != "" {
+		if r.change, err = i.bareSequence(chg); err != nil {
+			return err
+		}
+	}
+	for k, set := range r.change {
+		if len(set) != 1 && (k >= len(target) || len(set) != len(target[k])) {
+			return fmt.Errorf("expected one phoneme or as many as replaced in line %s", l)
+		}
+	}
+	if hasEnv {
+		f := strings.Fields(env)
+		n := 0
+		for k := range f {
+			if f[k] == "_" {
+				n++
+			}
+		}
+		if n != 1 {
+			return fmt.Errorf("expected single \"_\" in the environment in line %s", l)
+		}
+		before, after, _ := strings.Cut(" "+strings.Join(f, " ")+" ", " _ ")
+		if before = strings.TrimSpace(before); before != "" {
+			if r.left, err = i.bareSequence(before); err != nil {
+				return err
+			}
+		}
+		if after = strings.TrimSpace(after); after != "" {
+			if r.right, err = i.bareSequence(after); err != nil {
+				return err
+			}
+		}
+	}
+	i.phonology = append(i.phonology, r)
+	return nil
+}
+
+// apply rewrites the phonemes ph left to right with the rule r. Contexts are
+// matched against the phonemes already rewritten by the rule so that changes
+// can spread through the word.
+func (r *phonRule) apply(ph []string) []string {
+	for k := 0; k+len(r.target) <= len(ph); {
+		if !r.matches(ph, k) {
+			k++
+			continue
+		}
+		repl := r.rewrite(ph[k : k+len(r.target)])
+		out := append([]string{}, ph[:k]...)
+		out = append(out, repl...)
+		ph = append(out, ph[k+len(r.target):]...)
+		k += len(repl)
+	}
+	return ph
+}
+
+// matches reports whether the rule r applies to ph at the position k.
+func (r *phonRule) matches(ph []string, k int) bool {
+	at := func(pos int) (string, bool) {
+		switch {
+		case pos == -1 || pos == len(ph):
+			return boundary, true
+		case pos < -1 || pos > len(ph):
+			return "", false
+		}
+		return ph[pos], true
+	}
+	for j, set := range r.target {
+		if !contains(set, ph[k+j]) {
+			return false
+		}
+	}
+	for j, set := range r.left {
+		p, ok := at(k - len(r.left) + j)
+		if !ok || !contains(set, p) {
+			return false
+		}
+	}
+	for j, set := range r.right {
+		p, ok := at(k + len(r.target) + j)
+		if !ok || !contains(set, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// rewrite returns phonemes replacing the matched phonemes seg.
+func (r *phonRule) rewrite(seg []string) []string {
+	var out []string
+	for k, set := range r.change {
+		if len(set) == 1 {
+			out = append(out, set[0])
+			continue
+		}
+		for j, p := range r.target[k] {
+			if p == seg[k] {
+				out = append(out, set[j])
+				break
+			}
+		}
+	}
+	return out
+}
+
+// derive applies phonological rules in order to the phonemic transcript t.
+func (i *interpreter) derive(t string) Derivation {
+	d := Derivation{Input: t, Output: t}
+	if len(i.phonology) == 0 {
+		return d
+	}
+	ph := strings.Fields(t)
+	for k := range i.phonology {
+		r := &i.phonology[k]
+		before := strings.Join(ph, " ")
+		ph = r.apply(ph)
+		after := strings.Join(ph, " ")
+		if after == before {
+			continue
+		}
+		d.Steps = append(d.Steps, Step{
+			Rule:   r.text,
+			File:   r.file,
+			Line:   r.line,
+			Before: before,
+			After:  after,
+		})
+	}
+	d.Output = strings.Join(ph, " ")
+	return d
+}
+
+// contains reports whether the slice s holds the string v.
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package prg2p
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Fresh transcriber with grapheme-to-phoneme rules followed by a phonology
+// section.
+func phonologyG2P() (*G2P, error) {
+	var rules strings.Builder
+	rulesIO().WriteTo(&rules)
+	rules.WriteString(`
+[PHONOLOGY]
+VOICED = b, d, g, z, rz, w
+VOICELESS = p, t, k, s, sz, f
+# Progressive devoicing spreading over the cluster.
+VOICED -> VOICELESS / VOICELESS _
+# Nasal vowel decomposition before stops.
+e_ -> e n / _ (t, d)
+# Final devoicing.
+VOICED -> VOICELESS / _ #
+# Glide deletion between vowels.
+j -> 0 / (a, e) _ (a, e)
+`)
+	return Load(strings.NewReader(rules.String()))
+}
+
+// Check if phonological rules are parsed from the phonology section.
+func TestAsPhonRule(t *testing.T) {
+	i := newInterpreter()
+	i.vars["VOICED"] = []string{"b", "d"}
+	i.vars["VOICELESS"] = []string{"p", "t"}
+	valid := []string{
+		"e_ -> e n / _ (t, d)",
+		"(b, d) -> (p, t) / _ #",
+		"VOICED -> VOICELESS",
+		"j -> 0 / a _ a",
+		"j -> / # _",
+		"n k -> ng k",
+		"e_ -> e n / _",
+		"S -> sz / _ #",
+		"(OI) -> o j",
+		"VOICED -> VOICELESS / VOICELESS _ (OI)",
+	}
+	invalid := []string{
+		"VOICED -> VOICELESS / VOICELES _",
+		"VOICD -> p",
+		"SDD -> p / _ #",
+		"e_ => e n",
+		"(b, d) -> (p, t, k)",
+		"e_ -> e n / (t, d)",
+		"e_ -> e n / _ _ t",
+		"(b, d -> p",
+	}
+	for _, l := range valid {
+		if err := i.asPhonRule(l); err != nil {
+			t.Errorf("error was raised for %s: %s", l, err)
+		}
+	}
+	for _, l := range invalid {
+		if err := i.asPhonRule(l); err == nil {
+			t.Errorf("error was not raised for %s", l)
+		}
+	}
+}
+
+// Check if section headers switch between rule kinds.
+func TestSection(t *testing.T) {
+	i := newInterpreter()
+	r := strings.NewReader(`
+ALL = a, b, $
+[PHONOLOGY]
+b -> p / _ #
+[RULES]
+ALL	a	ALL	a`)
+	if err := i.scan(r); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(i.phonology) != 1 || len(i.rules) != 1 {
+		t.Errorf("have %d phonological and %d rules; want 1 and 1", len(i.phonology), len(i.rules))
+	}
+	if i.sect != rulesSection {
+		t.Errorf("have section %s after scan; want %s", i.sect, rulesSection)
+	}
+	if err := i.eval("[MORPHOLOGY]"); err == nil {
+		t.Error("unknown section should cause an error")
+	}
+}
+
+// Test if phonological rules apply in order to the output of
+// grapheme-to-phoneme rules and merge variants they make identical.
+func TestTranscribePhonology(t *testing.T) {
+	cases := []struct {
+		word string
+		want []string
+	}{
+		{"chleb", []string{"h l e p"}},
+		{"tkwi", []string{"t k f i"}},
+		{"krzyż", []string{"k sz y sz"}},
+		{"kręty", []string{"k r e n t y"}},
+		{"maja", []string{"m a a"}},
+		{"ala", []string{"a l a"}},
+	}
+	g2p, err := phonologyG2P()
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	for _, c := range cases {
+		t.Run(c.word, func(t *testing.T) {
+			have, err := g2p.Transcribe(c.word, true)
+			if err != nil {
+				t.Fatalf("failed to transcribe: %s", c.word)
+			}
+			if ok := reflect.DeepEqual(have, c.want); !ok {
+				t.Errorf("have %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Test if G2P.Derive() traces every phonological rule that changed the
+// transcript.
+func TestDerive(t *testing.T) {
+	g2p, err := phonologyG2P()
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	have, err := g2p.Derive("kręty")
+	if err != nil {
+		t.Fatalf("failed to derive: %s", err)
+	}
+	if len(have) == 0 {
+		t.Fatal("expected at least one derivation")
+	}
+	d := have[0]
+	if d.Input != "k r e_ t y" || d.Output != "k r e n t y" {
+		t.Errorf("have %s -> %s; want k r e_ t y -> k r e n t y", d.Input, d.Output)
+	}
+	want := []Step{{
+		Rule:   "e_ -> e n / _ (t, d)",
+		Line:   d.Steps[0].Line,
+		Before: "k r e_ t y",
+		After:  "k r e n t y",
+	}}
+	if ok := reflect.DeepEqual(d.Steps, want); !ok || d.Steps[0].Line == 0 {
+		t.Errorf("have %+v; want: %+v", d.Steps, want)
+	}
+}
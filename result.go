@@ -0,0 +1,204 @@
+package prg2p
+
+import (
+	"context"
+	"errors"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Case is the case class of a word as it was written before it was
+// lower-cased for transcription.
+type Case int
+
+const (
+	// Lower is the class of words with no upper-case letters, including
+	// words with no letters at all.
+	Lower Case = iota
+
+	// Title is the class of words with only the first letter upper-case.
+	Title
+
+	// Upper is the class of words with at least two letters, all of them
+	// upper-case, such as acronyms.
+	Upper
+
+	// Mixed is the class of the remaining words, for example "iPhone" or
+	// "McDonald".
+	Mixed
+)
+
+// caseNames are names of case classes returned by Case.String.
+var caseNames = [...]string{"lower", "title", "upper", "mixed"}
+
+// String returns the name of the case class.
+func (c Case) String() string {
+	if c < 0 || int(c) >= len(caseNames) {
+		return "unknown"
+	}
+	return caseNames[c]
+}
+
+// MarshalText encodes the case class as its name.
+func (c Case) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// CaseOf returns the case class of the word w.
+func CaseOf(w string) Case {
+	var letters, upper int
+	firstUpper := false
+	for _, r := range w {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		isUpper := unicode.IsUpper(r) || unicode.IsTitle(r)
+		if isUpper {
+			upper++
+		}
+		if letters == 0 {
+			firstUpper = isUpper
+		}
+		letters++
+	}
+	switch {
+	case upper == 0:
+		return Lower
+	case upper == 1 && firstUpper:
+		return Title
+	case upper == letters:
+		return Upper
+	}
+	return Mixed
+}
+
+// Result is the transcription of a single token. Start and End are byte
+// offsets of the token in the transcribed text and RuneStart and RuneEnd are
+// the same offsets counted in runes. Normalized is the form of the token
+// transcribed by rules after Unicode normalization, case folding and
+// character mapping.
+type Result struct {
+	Token      string   `json:"token"`
+	Normalized string   `json:"normalized"`
+	Case       Case     `json:"case"`
+	Start      int      `json:"start"`
+	End        int      `json:"end"`
+	RuneStart  int      `json:"rune_start"`
+	RuneEnd    int      `json:"rune_end"`
+	Spelled    bool     `json:"spelled,omitempty"`
+	Variants   []string `json:"variants"`
+}
+
+// TranscribeWord transcribes the word w like Transcribe and returns the
+// result carrying the word as it was given along with its normalized form
+// and case class. Offsets span the whole word. Words are spelled letter by
+// letter as set with WithAcronyms.
+func (g *G2P) TranscribeWord(w string, all bool) (Result, error) {
+	return g.TranscribeWordContext(context.Background(), w, all)
+}
+
+// TranscribeWordContext transcribes the word w like TranscribeWord but gives
+// up with the error of ctx once it is cancelled or its deadline passes.
+func (g *G2P) TranscribeWordContext(ctx context.Context, w string, all bool) (Result, error) {
+	res := Result{
+		Token:      w,
+		Normalized: g.prepare(w),
+		Case:       CaseOf(w),
+		End:        len(w),
+		RuneEnd:    utf8.RuneCountInString(w),
+	}
+	if res.Normalized == "" {
+		return res, ErrEmptyInput
+	}
+	key, transcribe := res.Normalized, g.transcribe
+	if res.Spelled = g.spells(res.Case, res.Normalized); res.Spelled {
+		key, transcribe = spellingKey(key), g.spell
+	}
+	out, ok := g.cache.get(key)
+	if !ok {
+		var err error
+		if out, err = transcribe(ctx, res.Normalized); err != nil {
+			return res, err
+		}
+		g.cache.put(key, out)
+	}
+	if all == true {
+		res.Variants = append([]string{}, out...)
+	} else {
+		res.Variants = []string{out[0]}
+	}
+	return res, nil
+}
+
+// TranscribeText transcribes each whitespace-separated token of the text s
+// and returns their results in order with offsets of the tokens in s. Tokens
+// that could not be transcribed have no variants and the returned error
+// joins their errors.
+func (g *G2P) TranscribeText(s string, all bool) ([]Result, error) {
+	return g.TranscribeTextContext(context.Background(), s, all)
+}
+
+// TranscribeTextContext transcribes the text s like TranscribeText. Once ctx
+// is cancelled or its deadline passes, it returns the results of tokens
+// transcribed so far along with the error of ctx.
+func (g *G2P) TranscribeTextContext(ctx context.Context, s string, all bool) ([]Result, error) {
+	var tokens []Result
+	start, runeStart, runes := -1, 0, 0
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		tokens = append(tokens, Result{
+			Token:     s[start:end],
+			Start:     start,
+			End:       end,
+			RuneStart: runeStart,
+			RuneEnd:   runes,
+		})
+		start = -1
+	}
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			flush(i)
+		} else if start < 0 {
+			start, runeStart = i, runes
+		}
+		runes++
+	}
+	flush(len(s))
+	return g.batch(ctx, tokens, all)
+}
+
+// TranscribeBatch transcribes the words ws and returns their results in the
+// same order. Words that could not be transcribed have no variants and the
+// returned error joins their errors. Once ctx is cancelled or its deadline
+// passes, it returns the results of words transcribed so far along with the
+// error of ctx.
+func (g *G2P) TranscribeBatch(ctx context.Context, ws []string, all bool) ([]Result, error) {
+	tokens := make([]Result, len(ws))
+	for k, w := range ws {
+		tokens[k] = Result{Token: w, End: len(w), RuneEnd: utf8.RuneCountInString(w)}
+	}
+	return g.batch(ctx, tokens, all)
+}
+
+// batch transcribes tokens keeping their offsets.
+func (g *G2P) batch(ctx context.Context, tokens []Result, all bool) ([]Result, error) {
+	var (
+		out  []Result
+		errs []error
+	)
+	for _, tok := range tokens {
+		res, err := g.TranscribeWordContext(ctx, tok.Token, all)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return out, ctxErr
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		res.Start, res.End = tok.Start, tok.End
+		res.RuneStart, res.RuneEnd = tok.RuneStart, tok.RuneEnd
+		out = append(out, res)
+	}
+	return out, errors.Join(errs...)
+}
@@ -0,0 +1,118 @@
+package prg2p
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Test if words are assigned their case classes.
+func TestCaseOf(t *testing.T) {
+	cases := []struct {
+		word string
+		want Case
+	}{
+		{"kota", Lower},
+		{"123", Lower},
+		{"Kraków", Title},
+		{"A", Title},
+		{"PKP", Upper},
+		{"ŁÓDŹ", Upper},
+		{"PKP-owi", Mixed},
+		{"iPhone", Mixed},
+		{"McDonald", Mixed},
+	}
+	for _, c := range cases {
+		if have := CaseOf(c.word); have != c.want {
+			t.Errorf("%s: have %s; want: %s", c.word, have, c.want)
+		}
+	}
+}
+
+// Test if the result keeps the word as it was given along with its
+// normalized form.
+func TestTranscribeWord(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	have, err := g2p.TranscribeWord("Łódź", false)
+	if err != nil {
+		t.Fatalf("failed to transcribe: %s", err)
+	}
+	want := Result{
+		Token:      "Łódź",
+		Normalized: "łódź",
+		Case:       Title,
+		End:        7,
+		RuneEnd:    4,
+		Variants:   []string{"l_ u ci"},
+	}
+	if ok := reflect.DeepEqual(have, want); !ok {
+		t.Errorf("have %+v; want: %+v", have, want)
+	}
+	if _, err := g2p.TranscribeWord("x$y", false); err == nil {
+		t.Error("word without rules should cause an error")
+	}
+}
+
+// Test if tokens of the text are transcribed with their offsets.
+func TestTranscribeText(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	have, err := g2p.TranscribeText(" Żółw  ma\tPKP ", false)
+	if err != nil {
+		t.Fatalf("failed to transcribe: %s", err)
+	}
+	want := []Result{
+		{Token: "Żółw", Normalized: "żółw", Case: Title, Start: 1, End: 8, RuneStart: 1, RuneEnd: 5, Variants: []string{"rz u l_ f"}},
+		{Token: "ma", Normalized: "ma", Case: Lower, Start: 10, End: 12, RuneStart: 7, RuneEnd: 9, Variants: []string{"m a"}},
+		{Token: "PKP", Normalized: "pkp", Case: Upper, Start: 13, End: 16, RuneStart: 10, RuneEnd: 13, Variants: []string{"p k p"}},
+	}
+	if ok := reflect.DeepEqual(have, want); !ok {
+		t.Errorf("have %+v; want: %+v", have, want)
+	}
+	have, err = g2p.TranscribeText("ma x$y kota", false)
+	if err == nil {
+		t.Error("token without rules should cause an error")
+	}
+	if len(have) != 3 || have[1].Variants != nil || have[2].Token != "kota" {
+		t.Errorf("failed tokens should be kept without variants: have %+v", have)
+	}
+}
+
+// Test if words of the batch are transcribed in order and the batch stops
+// once the context is cancelled.
+func TestTranscribeBatch(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	have, err := g2p.TranscribeBatch(context.Background(), []string{"Ala", "x$", "kota"}, false)
+	if !errors.Is(err, ErrNoRule) {
+		t.Errorf("have %v; want: %v", err, ErrNoRule)
+	}
+	var variants [][]string
+	for _, r := range have {
+		variants = append(variants, r.Variants)
+	}
+	want := [][]string{{"a l a"}, nil, {"k o t a"}}
+	if ok := reflect.DeepEqual(variants, want); !ok {
+		t.Errorf("have %v; want: %v", variants, want)
+	}
+	if have[0].Token != "Ala" || have[0].End != 3 || have[0].Case != Title {
+		t.Errorf("unexpected result %+v", have[0])
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	have, err = g2p.TranscribeBatch(ctx, []string{"ala", "kota"}, false)
+	if !errors.Is(err, context.Canceled) || len(have) != 0 {
+		t.Errorf("have %v, %v; want: no results, %v", have, err, context.Canceled)
+	}
+	if _, err := g2p.TranscribeTextContext(ctx, "ala ma kota", false); !errors.Is(err, context.Canceled) {
+		t.Errorf("have %v; want: %v", err, context.Canceled)
+	}
+}
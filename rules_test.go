@@ -1,6 +1,7 @@
 package prg2p
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -10,3 +11,93 @@ func TestRulesCall(t *testing.T) {
 	r := Rules()
 	r.Read(buf)
 }
+
+// Test if the default rules are the standard rule set with its includes
+// inlined.
+func TestRulesStandard(t *testing.T) {
+	a, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to load default rules")
+	}
+	b, err := LoadNamed("standard")
+	if err != nil {
+		t.Fatal("failed to load standard rule set")
+	}
+	words := []string{"chleb", "trzy", "gęś", "drzewo", "liść", "Zażółć"}
+	if changes := Diff(a, b, words); len(changes) != 0 {
+		t.Errorf("have %v; want no changes", changes)
+	}
+}
+
+// Test if shipped rule sets can be loaded by name and transcribe words in
+// their own way.
+func TestLoadNamed(t *testing.T) {
+	cases := []struct {
+		name string
+		word string
+		want []string
+	}{
+		{"standard", "trzy", []string{"t sz y", "cz y"}},
+		{"careful", "trzy", []string{"t sz y"}},
+		{"careful", "chleb", []string{"h l e p"}},
+		{"careful", "wąs", []string{"w a_ s"}},
+		{"regional", "kot", []string{"k o t", "k o d"}},
+		{"regional", "liść", []string{"l i si ci", "l i si dzi"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name+"/"+c.word, func(t *testing.T) {
+			g2p, err := LoadNamed(c.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			have, err := g2p.Transcribe(c.word, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(have, "|") != strings.Join(c.want, "|") {
+				t.Errorf("have %v; want: %v", have, c.want)
+			}
+		})
+	}
+	if _, err := LoadNamed("nope"); err == nil {
+		t.Error("expected unknown rule set to fail")
+	}
+}
+
+// Test if shipped rule sets replace rules of the standard set without
+// overwriting them, so that the only conflicts are those of the standard set.
+func TestRuleSetsOverwrites(t *testing.T) {
+	std, err := LoadNamed("standard")
+	if err != nil {
+		t.Fatal("failed to load standard rule set")
+	}
+	want := make(map[string]bool)
+	for _, o := range std.Overwrites() {
+		want[o.Left+"["+o.Source+"]"+o.Right+" "+o.Reason] = true
+	}
+	for _, info := range RuleSets() {
+		g2p, err := LoadNamed(info.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, o := range g2p.Overwrites() {
+			if !want[o.Left+"["+o.Source+"]"+o.Right+" "+o.Reason] {
+				t.Errorf("%s: have %s; want no overwrites beyond standard", info.Name, o)
+			}
+		}
+	}
+}
+
+// Test if RuleSets lists every shipped rule set with the default one first.
+func TestRuleSets(t *testing.T) {
+	var names []string
+	for _, rs := range RuleSets() {
+		if rs.Description == "" {
+			t.Errorf("rule set %s has no description", rs.Name)
+		}
+		names = append(names, rs.Name)
+	}
+	if have, want := strings.Join(names, " "), "standard careful regional"; have != want {
+		t.Errorf("have %s; want: %s", have, want)
+	}
+}
@@ -0,0 +1,256 @@
+package prg2p
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleSet is the structured representation of a rule file. It can be built
+// in memory, decoded from JSON or YAML with DecodeRuleSet, read from the line
+// format with ReadRuleSet and written back in the line format with WriteTo.
+// Statements keep the order of the rule file, so a variable redefined
+// between rules applies only to the rules that follow it.
+//
+// Example in YAML:
+//
+//	meta:
+//	  name: standard
+//	  version: 1.0.0
+//	  dialect: standard
+//	map:
+//	  ’: "'"
+//	statements:
+//	  - comment: "# FINAL DEVOICING"
+//	  - var: {name: SB, values: [p, t, k]}
+//	  - var: {name: END, values: [$]}
+//	  - rule: {left: EMPTY, source: b, right: END, targets: [p, b]}
+//	tests:
+//	  - {word: bok, transcripts: [b o k]}
+type RuleSet struct {
+	Meta       map[string]string `json:"meta,omitempty" yaml:"meta,omitempty"`
+	Map        map[string]string `json:"map,omitempty" yaml:"map,omitempty"`
+	Statements []Statement       `json:"statements,omitempty" yaml:"statements,omitempty"`
+	Tests      []TestCase        `json:"tests,omitempty" yaml:"tests,omitempty"`
+}
+
+// Statement is a line of the rule file: a comment, an included file, a
+// variable, a grapheme-to-phoneme rule or a phonological rule in the A -> B /
+// L _ R format. Exactly one of its fields is set.
+type Statement struct {
+	Comment   string   `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Include   string   `json:"include,omitempty" yaml:"include,omitempty"`
+	Var       *VarDef  `json:"var,omitempty" yaml:"var,omitempty"`
+	Rule      *RuleDef `json:"rule,omitempty" yaml:"rule,omitempty"`
+	Phonology string   `json:"phonology,omitempty" yaml:"phonology,omitempty"`
+}
+
+// VarDef declares a variable with either a list of values or a set
+// expression computing them from other variables.
+type VarDef struct {
+	Name   string   `json:"name" yaml:"name"`
+	Values []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Expr   string   `json:"expr,omitempty" yaml:"expr,omitempty"`
+}
+
+// RuleDef declares a grapheme-to-phoneme rule. Contexts are variable names or
+// set expressions as in the line format.
+type RuleDef struct {
+	Left     string   `json:"left" yaml:"left"`
+	Source   string   `json:"source" yaml:"source"`
+	Right    string   `json:"right" yaml:"right"`
+	Targets  []string `json:"targets" yaml:"targets"`
+	Priority int      `json:"priority,omitempty" yaml:"priority,omitempty"`
+}
+
+// String returns the rule in the line format.
+func (r RuleDef) String() string {
+	return r.Left + "\t" + r.Source + "\t" + r.Right + "\t" + strings.Join(r.Targets, ", ")
+}
+
+// LoadRuleSet returns a fully initialized G2P object with rules from the rule
+// set rs and configured with opts. Included files are resolved relative to
+// the working directory.
+func LoadRuleSet(rs *RuleSet, opts ...Option) (*G2P, error) {
+	if rs == nil {
+		return nil, fmt.Errorf("rule set is nil")
+	}
+	var buf bytes.Buffer
+	if _, err := rs.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return Load(&buf, opts...)
+}
+
+// DecodeRuleSet decodes the rule set from JSON or YAML read from r.
+func DecodeRuleSet(r io.Reader) (*RuleSet, error) {
+	var rs RuleSet
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&rs); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("could not decode rule set: %w", err)
+	}
+	return &rs, nil
+}
+
+// ReadRuleSet reads the rule set from the rule file in the line format read
+// from r. Statements and comments keep their order, blank lines are dropped
+// and included files are listed as statements without being read.
+func ReadRuleSet(r io.Reader) (*RuleSet, error) {
+	if r == nil {
+		return nil, errScan
+	}
+	rs := RuleSet{Meta: make(map[string]string), Map: make(map[string]string)}
+	declared := &interpreter{vars: make(map[string][]string)}
+	sect, priority := rulesSection, 0
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		l := strings.TrimSpace(s.Text())
+		err := func() error {
+			switch {
+			case isInclude(l):
+				name, err := strconv.Unquote(strings.TrimSpace(strings.TrimPrefix(l, includeDirective)))
+				if err != nil || name == "" {
+					return fmt.Errorf("expected quoted file name")
+				}
+				rs.Statements = append(rs.Statements, Statement{Include: name})
+			case l == "":
+			case strings.HasPrefix(l, "#"):
+				rs.Statements = append(rs.Statements, Statement{Comment: l})
+			case strings.HasPrefix(l, "@"):
+				d, err := parseDirective(l)
+				if err != nil {
+					return err
+				}
+				switch d.name {
+				case priorityDirective:
+					priority = d.priority
+				case testDirective:
+					rs.Tests = append(rs.Tests, d.test)
+				default:
+					rs.Meta[d.key] = d.val
+				}
+			case isMap(l):
+				from, to, err := parseMap(l)
+				if err != nil {
+					return err
+				}
+				rs.Map[from] = to
+			case strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]"):
+				if l != rulesSection && l != phonologySection {
+					return fmt.Errorf("unknown section %s", l)
+				}
+				sect = l
+			case sect == phonologySection && !strings.Contains(l, "="):
+				rs.Statements = append(rs.Statements, Statement{Phonology: l})
+			case strings.Contains(l, "="):
+				name, val, _ := strings.Cut(l, "=")
+				v := VarDef{Name: strings.TrimSpace(name)}
+				if val = strings.TrimSpace(val); !declared.isExpr(val) {
+					for _, e := range strings.Split(val, ",") {
+						v.Values = append(v.Values, strings.TrimSpace(e))
+					}
+				} else {
+					v.Expr = val
+				}
+				declared.vars[v.Name] = nil
+				rs.Statements = append(rs.Statements, Statement{Var: &v})
+			default:
+				cols := columns(l)
+				if len(cols) != 4 {
+					return fmt.Errorf("expected 4 columns")
+				}
+				rd := RuleDef{Left: cols[0], Source: cols[1], Right: cols[2], Priority: priority}
+				for _, t := range strings.Split(cols[3], ",") {
+					rd.Targets = append(rd.Targets, strings.TrimSpace(t))
+				}
+				rs.Statements = append(rs.Statements, Statement{Rule: &rd})
+			}
+			return nil
+		}()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n, l, err)
+		}
+	}
+	if len(rs.Meta) == 0 {
+		rs.Meta = nil
+	}
+	if len(rs.Map) == 0 {
+		rs.Map = nil
+	}
+	return &rs, s.Err()
+}
+
+// WriteTo writes the rule set to w in the line format. It implements the
+// io.WriterTo interface.
+func (rs *RuleSet) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	var keys []string
+	for k := range rs.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range headerKeys {
+		if v, ok := rs.Meta[k]; ok {
+			b.WriteString("@" + k + " " + v + "\n")
+		}
+	}
+	for _, k := range keys {
+		if !isHeaderKey(k) {
+			b.WriteString(metaDirective + " " + k + " " + rs.Meta[k] + "\n")
+		}
+	}
+	keys = keys[:0]
+	for k := range rs.Map {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(strings.TrimSpace(mapKeyword+" "+k+" = "+rs.Map[k]) + "\n")
+	}
+	sect, priority := rulesSection, 0
+	for _, st := range rs.Statements {
+		switch {
+		case st.Comment != "":
+			if !strings.HasPrefix(st.Comment, "#") {
+				b.WriteString("# ")
+			}
+			b.WriteString(st.Comment + "\n")
+		case st.Include != "":
+			b.WriteString(includeDirective + " " + strconv.Quote(st.Include) + "\n")
+		case st.Var != nil:
+			val := st.Var.Expr
+			if val == "" {
+				val = strings.Join(st.Var.Values, ", ")
+			}
+			b.WriteString(st.Var.Name + " = " + val + "\n")
+		case st.Rule != nil:
+			if sect != rulesSection {
+				sect = rulesSection
+				b.WriteString(sect + "\n")
+			}
+			if st.Rule.Priority != priority {
+				priority = st.Rule.Priority
+				b.WriteString(priorityDirective + " " + strconv.Itoa(priority) + "\n")
+			}
+			b.WriteString(st.Rule.String() + "\n")
+		case st.Phonology != "":
+			if sect != phonologySection {
+				sect = phonologySection
+				b.WriteString(sect + "\n")
+			}
+			b.WriteString(st.Phonology + "\n")
+		}
+	}
+	for _, tc := range rs.Tests {
+		b.WriteString(testDirective + " " + tc.String() + "\n")
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
@@ -0,0 +1,232 @@
+package prg2p
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// Rule file in the line format for testing rule set conversions.
+const ruleSetRules = `# Comments are kept.
+@meta dialect standard
+#include "base.txt"
+ALL = a, b, k, o, t
+EMPTY = *
+END = $
+SB = k, t
+SV = ALL-SB
+EMPTY	b	END	p, b
+@priority 5
+EMPTY	k	EMPTY	k
+[PHONOLOGY]
+V = b
+V -> p / _ #
+@test bok b o k
+`
+
+// Test if ReadRuleSet reads all kinds of statements of the line format.
+func TestReadRuleSet(t *testing.T) {
+	want := &RuleSet{
+		Meta: map[string]string{"dialect": "standard"},
+		Statements: []Statement{
+			{Comment: "# Comments are kept."},
+			{Include: "base.txt"},
+			{Var: &VarDef{Name: "ALL", Values: []string{"a", "b", "k", "o", "t"}}},
+			{Var: &VarDef{Name: "EMPTY", Values: []string{"*"}}},
+			{Var: &VarDef{Name: "END", Values: []string{"$"}}},
+			{Var: &VarDef{Name: "SB", Values: []string{"k", "t"}}},
+			{Var: &VarDef{Name: "SV", Expr: "ALL-SB"}},
+			{Rule: &RuleDef{Left: "EMPTY", Source: "b", Right: "END", Targets: []string{"p", "b"}}},
+			{Rule: &RuleDef{Left: "EMPTY", Source: "k", Right: "EMPTY", Targets: []string{"k"}, Priority: 5}},
+			{Var: &VarDef{Name: "V", Values: []string{"b"}}},
+			{Phonology: "V -> p / _ #"},
+		},
+		Tests: []TestCase{{Word: "bok", Transcripts: []string{"b o k"}}},
+	}
+	have, err := ReadRuleSet(strings.NewReader(ruleSetRules))
+	if err != nil {
+		t.Fatalf("failed to read rule set: %s", err)
+	}
+	if ok := reflect.DeepEqual(have, want); !ok {
+		t.Errorf("have %+v; want: %+v", have, want)
+	}
+}
+
+// Test if ReadRuleSet errors out on malformed lines.
+func TestReadRuleSetFails(t *testing.T) {
+	cases := []string{
+		"EMPTY\tb\tEND",
+		"#include base.txt",
+		"@priority high",
+		"@test bok",
+		"@unknown",
+		"[LEXICON]",
+	}
+	for _, src := range cases {
+		if _, err := ReadRuleSet(strings.NewReader(src)); err == nil {
+			t.Errorf("rules %q should cause an error", src)
+		}
+	}
+	var r io.Reader
+	if _, err := ReadRuleSet(r); err == nil {
+		t.Error("nil interface should cause an error")
+	}
+}
+
+// Test if the default rules converted to a rule set and to JSON and back
+// transcribe words like the original rules.
+func TestRuleSetRoundTrip(t *testing.T) {
+	rs, err := ReadRuleSet(Rules())
+	if err != nil {
+		t.Fatalf("failed to read rule set: %s", err)
+	}
+	b, err := json.Marshal(rs)
+	if err != nil {
+		t.Fatalf("failed to encode rule set: %s", err)
+	}
+	decoded, err := DecodeRuleSet(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("failed to decode rule set: %s", err)
+	}
+	if ok := reflect.DeepEqual(decoded, rs); !ok {
+		t.Error("decoded rule set differs from the encoded one")
+	}
+	a, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	g, err := LoadRuleSet(decoded)
+	if err != nil {
+		t.Fatalf("failed to load rule set: %s", err)
+	}
+	words := strings.Fields("ala ma kota przy krzyk także chleb mówię zima wieś dżem dźwięk prośba tchórz")
+	if changes := Diff(a, g, words); len(changes) != 0 {
+		t.Errorf("have %d changed words; want none", len(changes))
+	}
+}
+
+// Test if a variable redefined between rules keeps its meaning for the
+// rules on either side of the redefinition after a round trip through the
+// rule set, JSON and back to the line format.
+func TestRuleSetRoundTripOrder(t *testing.T) {
+	src := `# Rules before and after B is redefined.
+ALL = a, b, k, $
+EMPTY = *
+B = k
+EMPTY	a	EMPTY	a
+EMPTY	k	EMPTY	k
+EMPTY	b	EMPTY	b
+EMPTY	b	B	p
+B = a
+EMPTY	a	B	o
+`
+	a, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("failed to create G2P transcriber: %s", err)
+	}
+	rs, err := ReadRuleSet(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("failed to read rule set: %s", err)
+	}
+	b, err := json.Marshal(rs)
+	if err != nil {
+		t.Fatalf("failed to encode rule set: %s", err)
+	}
+	decoded, err := DecodeRuleSet(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("failed to decode rule set: %s", err)
+	}
+	var buf bytes.Buffer
+	if _, err := decoded.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "# Rules before and after B is redefined.\n") {
+		t.Errorf("have %q; want the comment kept", buf.String())
+	}
+	g, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("failed to load rule set: %s", err)
+	}
+	for _, w := range []string{"bk", "ba", "aa", "bab"} {
+		want, err := a.Transcribe(w, true)
+		if err != nil {
+			t.Fatalf("failed to transcribe: %s", w)
+		}
+		have, err := g.Transcribe(w, true)
+		if err != nil {
+			t.Fatalf("failed to transcribe: %s", w)
+		}
+		if ok := reflect.DeepEqual(have, want); !ok {
+			t.Errorf("%s: have %v; want: %v", w, have, want)
+		}
+	}
+}
+
+// Test if DecodeRuleSet reads YAML and rejects unknown fields.
+func TestDecodeRuleSet(t *testing.T) {
+	src := `
+statements:
+  - var: {name: ALL, values: [a, b, o, k]}
+  - var: {name: EMPTY, values: ["*"]}
+  - var: {name: END, values: [$]}
+  - rule: {left: EMPTY, source: a, right: EMPTY, targets: [a]}
+  - rule: {left: EMPTY, source: b, right: END, targets: [p, b]}
+  - rule: {left: EMPTY, source: b, right: -END, targets: [b]}
+  - rule: {left: EMPTY, source: o, right: EMPTY, targets: [o]}
+  - rule: {left: EMPTY, source: k, right: EMPTY, targets: [k]}
+tests:
+  - {word: bob, transcripts: [b o p, b o b]}
+`
+	rs, err := DecodeRuleSet(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("failed to decode rule set: %s", err)
+	}
+	g, err := LoadRuleSet(rs)
+	if err != nil {
+		t.Fatalf("failed to load rule set: %s", err)
+	}
+	if err := g.Check(); err != nil {
+		t.Errorf("tests of the rule set failed: %s", err)
+	}
+	if _, err := DecodeRuleSet(strings.NewReader("rulez: []")); err == nil {
+		t.Error("unknown field should cause an error")
+	}
+	if _, err := LoadRuleSet(nil); err == nil {
+		t.Error("nil rule set should cause an error")
+	}
+}
+
+// Test if rule files in JSON and YAML are loaded by extension and can be
+// included from the line format and the other way round.
+func TestLoadFSStructured(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.yaml": {Data: []byte(`
+statements:
+  - var: {name: ALL, values: [a, b, o, k]}
+  - var: {name: EMPTY, values: ["*"]}
+  - var: {name: END, values: [$]}
+`)},
+		"rules.txt": {Data: []byte("#include \"base.yaml\"\nEMPTY\ta\tEMPTY\ta\n")},
+		"main.json": {Data: []byte(`{
+  "statements": [
+    {"include": "rules.txt"},
+    {"rule": {"left": "EMPTY", "source": "b", "right": "EMPTY", "targets": ["b"]}}
+  ]
+}`)},
+	}
+	g, err := LoadFS(fsys, "main.json")
+	if err != nil {
+		t.Fatalf("failed to load rules: %s", err)
+	}
+	have, err := g.Transcribe("baba", false)
+	if err != nil {
+		t.Fatalf("failed to transcribe: %s", err)
+	}
+	if ok := reflect.DeepEqual(have, []string{"b a b a"}); !ok {
+		t.Errorf("have %v; want: [b a b a]", have)
+	}
+}
@@ -0,0 +1,219 @@
+package prg2p
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Encoder writes results of transcription to the output of Stream.
+type Encoder interface {
+	Encode(r Result) error
+}
+
+// EncoderFunc is an adapter that lets an ordinary function be used as
+// Encoder.
+type EncoderFunc func(r Result) error
+
+// Encode calls f(r).
+func (f EncoderFunc) Encode(r Result) error {
+	return f(r)
+}
+
+// NewTSVEncoder returns the encoder writing one result per line to w with
+// tab-separated token, the number of variants and variants separated with
+// "|", like the prg2p command does.
+func NewTSVEncoder(w io.Writer) Encoder {
+	return EncoderFunc(func(r Result) error {
+		l := r.Token + "\t" + strconv.Itoa(len(r.Variants)) + "\t" + strings.Join(r.Variants, "|") + "\n"
+		_, err := io.WriteString(w, l)
+		return err
+	})
+}
+
+// NewJSONEncoder returns the encoder writing one result per line to w as a
+// JSON object.
+func NewJSONEncoder(w io.Writer) Encoder {
+	enc := json.NewEncoder(w)
+	return EncoderFunc(func(r Result) error {
+		return enc.Encode(r)
+	})
+}
+
+// StreamOptions configure Stream.
+type StreamOptions struct {
+	// All makes Stream output all variants instead of the first one.
+	All bool
+
+	// Workers is the number of words transcribed in parallel. Results are
+	// written in the order of words regardless. One worker is used if it is
+	// not positive.
+	Workers int
+
+	// NewEncoder returns the encoder writing results to the output.
+	// NewTSVEncoder is used if it is nil.
+	NewEncoder func(w io.Writer) Encoder
+
+	// OnError is called with the result and the error of each word that
+	// could not be transcribed. The word is skipped if it returns nil and
+	// Stream stops with the error it returns otherwise. Stream stops with
+	// the error of the first such word if OnError is nil.
+	OnError func(r Result, err error) error
+}
+
+// record is a word read by Stream along with the channel its result is sent
+// on once it is transcribed.
+type record struct {
+	token      Result
+	transcript chan outcome
+}
+
+// outcome is the result of transcribing a record.
+type outcome struct {
+	res Result
+	err error
+}
+
+// Stream reads whitespace-separated words from r, transcribes them and writes
+// the results to w with the encoder set in opts. Offsets of results are
+// counted from the start of r. At most a few words per worker are held in
+// memory at a time. Stream returns the error of ctx once it is cancelled or
+// its deadline passes, even while it waits for a Read of r that blocks.
+// Workers are done by the time Stream returns, but the goroutine reading r
+// stops only once the pending Read returns, so r must not be read by the
+// caller after Stream failed.
+func (g *G2P) Stream(ctx context.Context, r io.Reader, w io.Writer, opts StreamOptions) error {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	newEncoder := opts.NewEncoder
+	if newEncoder == nil {
+		newEncoder = NewTSVEncoder
+	}
+	enc := newEncoder(w)
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan record)
+	queue := make(chan record, 2*workers)
+	var (
+		wg      sync.WaitGroup
+		scanErr error
+	)
+	scanned := make(chan struct{})
+	go func() {
+		defer close(scanned)
+		defer close(queue)
+		defer close(jobs)
+		s := bufio.NewScanner(r)
+		var next Result // Offsets of the word returned by the next Scan.
+		pos, runes := 0, 0
+		s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			adv, tok, err := bufio.ScanWords(data, atEOF)
+			if tok != nil {
+				start := cap(data) - cap(tok)
+				next = Result{
+					Start:     pos + start,
+					End:       pos + start + len(tok),
+					RuneStart: runes + utf8.RuneCount(data[:start]),
+				}
+				next.RuneEnd = next.RuneStart + utf8.RuneCount(tok)
+			}
+			pos += adv
+			runes += utf8.RuneCount(data[:adv])
+			return adv, tok, err
+		})
+		for s.Scan() {
+			next.Token = s.Text()
+			rec := record{token: next, transcript: make(chan outcome, 1)}
+			select {
+			case queue <- rec:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- rec:
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanErr = s.Err()
+	}()
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var rec record
+				select {
+				case job, ok := <-jobs:
+					if !ok {
+						return
+					}
+					rec = job
+				case <-ctx.Done():
+					return
+				}
+				res, err := g.TranscribeWordContext(ctx, rec.token.Token, opts.All)
+				res.Start, res.End = rec.token.Start, rec.token.End
+				res.RuneStart, res.RuneEnd = rec.token.RuneStart, rec.token.RuneEnd
+				rec.transcript <- outcome{res, err}
+			}
+		}()
+	}
+
+	err := func() error {
+		for {
+			var rec record
+			select {
+			case job, ok := <-queue:
+				if !ok {
+					return nil
+				}
+				rec = job
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			var o outcome
+			select {
+			case o = <-rec.transcript:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if o.err != nil {
+				if opts.OnError == nil {
+					return o.err
+				}
+				if err := opts.OnError(o.res, o.err); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := enc.Encode(o.res); err != nil {
+				return fmt.Errorf("could not write %s: %w", o.res.Token, err)
+			}
+		}
+	}()
+	cancel()
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	if err := parent.Err(); err != nil {
+		return err
+	}
+	// The queue is closed, so the reader is about to finish.
+	<-scanned
+	return scanErr
+}
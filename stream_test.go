@@ -0,0 +1,215 @@
+package prg2p
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test if words are streamed to the output in the TSV format.
+func TestStream(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	var b strings.Builder
+	err = g2p.Stream(context.Background(), strings.NewReader("ala ma\nchleb  "), &b, StreamOptions{All: true})
+	if err != nil {
+		t.Fatalf("failed to stream: %s", err)
+	}
+	want := "ala\t1\ta l a\nma\t1\tm a\nchleb\t2\th l e p|h l e b\n"
+	if have := b.String(); have != want {
+		t.Errorf("have %q; want: %q", have, want)
+	}
+}
+
+// Test if JSON results carry offsets of words counted from the start of the
+// input.
+func TestStreamJSON(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	var b bytes.Buffer
+	opts := StreamOptions{NewEncoder: NewJSONEncoder}
+	if err := g2p.Stream(context.Background(), strings.NewReader(" Żółw\n\tPKP"), &b, opts); err != nil {
+		t.Fatalf("failed to stream: %s", err)
+	}
+	var have []Result
+	dec := json.NewDecoder(&b)
+	for dec.More() {
+		var r struct {
+			Result
+			Case string `json:"case"`
+		}
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("failed to decode: %s", err)
+		}
+		have = append(have, r.Result)
+	}
+	want := []Result{
+		{Token: "Żółw", Normalized: "żółw", Start: 1, End: 8, RuneStart: 1, RuneEnd: 5, Variants: []string{"rz u l_ f"}},
+		{Token: "PKP", Normalized: "pkp", Start: 10, End: 13, RuneStart: 7, RuneEnd: 10, Variants: []string{"p k p"}},
+	}
+	if ok := reflect.DeepEqual(have, want); !ok {
+		t.Errorf("have %+v; want: %+v", have, want)
+	}
+}
+
+// Test if words transcribed in parallel are written in the input order.
+func TestStreamWorkers(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	words := strings.Repeat("ala ma kota przy krzyk także chleb mówię zima wieś dżem dźwięk prośba tchórz ", 50)
+	var seq, par strings.Builder
+	if err := g2p.Stream(context.Background(), strings.NewReader(words), &seq, StreamOptions{All: true}); err != nil {
+		t.Fatalf("failed to stream: %s", err)
+	}
+	if err := g2p.Stream(context.Background(), strings.NewReader(words), &par, StreamOptions{All: true, Workers: 8}); err != nil {
+		t.Fatalf("failed to stream: %s", err)
+	}
+	if seq.String() != par.String() {
+		t.Error("parallel output differs from sequential output")
+	}
+	if n := strings.Count(par.String(), "\n"); n != 700 {
+		t.Errorf("have %d lines; want: 700", n)
+	}
+}
+
+// Test if words that fail to transcribe stop the stream or are handed to
+// OnError.
+func TestStreamErrors(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	input := "ala x$ ma y$ kota"
+	var b strings.Builder
+	err = g2p.Stream(context.Background(), strings.NewReader(input), &b, StreamOptions{})
+	if !errors.Is(err, ErrNoRule) {
+		t.Errorf("have %v; want: %v", err, ErrNoRule)
+	}
+	if have := b.String(); have != "ala\t1\ta l a\n" {
+		t.Errorf("have %q; want words before the failed one", have)
+	}
+
+	var failed []string
+	b.Reset()
+	opts := StreamOptions{
+		Workers: 3,
+		OnError: func(r Result, err error) error {
+			failed = append(failed, r.Token)
+			return nil
+		},
+	}
+	if err := g2p.Stream(context.Background(), strings.NewReader(input), &b, opts); err != nil {
+		t.Fatalf("failed to stream: %s", err)
+	}
+	if ok := reflect.DeepEqual(failed, []string{"x$", "y$"}); !ok {
+		t.Errorf("have %v; want: [x$ y$]", failed)
+	}
+	if n := strings.Count(b.String(), "\n"); n != 3 {
+		t.Errorf("have %d lines; want: 3", n)
+	}
+
+	stop := errors.New("stop")
+	enc := func(w io.Writer) Encoder {
+		return EncoderFunc(func(r Result) error { return stop })
+	}
+	if err := g2p.Stream(context.Background(), strings.NewReader(input), &b, StreamOptions{NewEncoder: enc}); !errors.Is(err, stop) {
+		t.Errorf("have %v; want: %v", err, stop)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := g2p.Stream(ctx, strings.NewReader(input), &b, StreamOptions{Workers: 2}); !errors.Is(err, context.Canceled) {
+		t.Errorf("have %v; want: %v", err, context.Canceled)
+	}
+}
+
+// blockingReader returns the words it holds and then blocks until release
+// is closed, like a pipe waiting for more data.
+type blockingReader struct {
+	words   string
+	release chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	if r.words != "" {
+		n := copy(p, r.words)
+		r.words = r.words[n:]
+		return n, nil
+	}
+	<-r.release
+	return 0, io.EOF
+}
+
+// waitGoroutines reports an error if the number of goroutines does not drop
+// to base within a second.
+func waitGoroutines(t *testing.T, base int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > base {
+		t.Errorf("have %d goroutines; want at most %d", n, base)
+	}
+}
+
+// Test if Stream aborted by OnError returns while its reader is blocked and
+// leaves no goroutines behind once the pending read returns.
+func TestStreamAbortWaits(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	base := runtime.NumGoroutine()
+	stop := errors.New("stop")
+	r := &blockingReader{words: "x$ ", release: make(chan struct{})}
+	opts := StreamOptions{
+		Workers: 4,
+		OnError: func(r Result, err error) error { return stop },
+	}
+	if err := g2p.Stream(context.Background(), r, io.Discard, opts); !errors.Is(err, stop) {
+		t.Errorf("have %v; want: %v", err, stop)
+	}
+	close(r.release)
+	waitGoroutines(t, base)
+}
+
+// Test if Stream gives up once the deadline passes even though its reader
+// is blocked waiting for more words.
+func TestStreamTimeoutBlockedReader(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	base := runtime.NumGoroutine()
+	r := &blockingReader{words: "ala ", release: make(chan struct{})}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	start := time.Now()
+	err = g2p.Stream(ctx, r, &out, StreamOptions{Workers: 2})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("have %v; want: %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("have Stream return after %s; want right after the deadline", d)
+	}
+	if have, want := out.String(), "ala\t1\ta l a\n"; have != want {
+		t.Errorf("have %q; want: %q", have, want)
+	}
+	close(r.release)
+	waitGoroutines(t, base)
+}
@@ -0,0 +1,259 @@
+package prg2p
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Transcriber converts words to phonemic transcripts. It is implemented by
+// G2P, Lexicon and Chain so that dictionaries can be composed with rules and
+// transcribers can be mocked in tests.
+type Transcriber interface {
+	// Transcribe returns transcripts of the word w, all of them or just the
+	// first one.
+	Transcribe(w string, all bool) ([]string, error)
+
+	// Align splits the word w into segments transcribed together.
+	Align(w string) ([]Segment, error)
+
+	// Inventory returns the sorted phonemes the transcriber may output.
+	Inventory() []string
+}
+
+// ErrUnknownWord is reported by Lexicon for words it has no transcripts of.
+var ErrUnknownWord = errors.New("unknown word")
+
+// Inventory returns the sorted phonemes output by grapheme-to-phoneme and
+// phonological rules and listed in the lexicon set with WithLexicon.
+func (g *G2P) Inventory() []string {
+	seen := make(map[string]bool)
+	for _, p := range g.lexicon.Inventory() {
+		seen[p] = true
+	}
+	if g.interp == nil {
+		return sortedKeys(seen)
+	}
+	for _, r := range g.interp.rules {
+		for _, t := range r.target {
+			for _, p := range strings.Fields(t) {
+				seen[p] = true
+			}
+		}
+	}
+	for _, r := range g.interp.phonology {
+		for _, c := range r.change {
+			for _, p := range c {
+				if p != deletion {
+					seen[p] = true
+				}
+			}
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// Lexicon is the dictionary of words with their transcripts. Words are looked
+// up in NFC and lower-cased, or prepared like words transcribed with rules
+// when the lexicon is passed to WithLexicon.
+type Lexicon struct {
+	entries map[string][]string
+	fold    func(string) string // Prepares words to look up; NFC and lower case if nil.
+}
+
+// NewLexicon returns the lexicon of words with their transcripts. Phonemes of
+// transcripts are separated with spaces.
+func NewLexicon(entries map[string][]string) *Lexicon {
+	lex := Lexicon{entries: make(map[string][]string)}
+	for w, ts := range entries {
+		for _, t := range ts {
+			lex.add(w, t)
+		}
+	}
+	return &lex
+}
+
+// ReadLexicon reads the lexicon from r with one word and its transcript
+// separated with a tab per line. Words with more than one transcript are
+// listed on several lines.
+//
+// Example:
+//
+//	chleb	h l e p
+//	chleb	h l e b
+func ReadLexicon(r io.Reader) (*Lexicon, error) {
+	if r == nil {
+		return nil, errScan
+	}
+	lex := Lexicon{entries: make(map[string][]string)}
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		l := strings.TrimSpace(s.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		w, t, ok := strings.Cut(l, "\t")
+		if !ok || strings.TrimSpace(w) == "" || strings.TrimSpace(t) == "" {
+			return nil, fmt.Errorf("expected word and transcript on line %d", n)
+		}
+		lex.add(w, t)
+	}
+	return &lex, s.Err()
+}
+
+// add adds the transcript t of the word w unless it is already listed.
+func (l *Lexicon) add(w, t string) {
+	w = l.key(w)
+	t = strings.Join(strings.Fields(t), " ")
+	if t == "" || contains(l.entries[w], t) {
+		return
+	}
+	l.entries[w] = append(l.entries[w], t)
+}
+
+// key returns the word w as it is looked up.
+func (l *Lexicon) key(w string) string {
+	if l.fold != nil {
+		return l.fold(w)
+	}
+	return strings.ToLower(norm.NFC.String(strings.TrimSpace(w)))
+}
+
+// withFold returns the copy of the lexicon with words looked up as prepared
+// by fold.
+func (l *Lexicon) withFold(fold func(string) string) *Lexicon {
+	out := &Lexicon{entries: make(map[string][]string), fold: fold}
+	for w, ts := range l.entries {
+		for _, t := range ts {
+			out.add(w, t)
+		}
+	}
+	return out
+}
+
+// lookup returns transcripts of the word w already prepared for look up. It
+// is safe to call on the nil lexicon.
+func (l *Lexicon) lookup(w string) ([]string, bool) {
+	if l == nil {
+		return nil, false
+	}
+	ts, ok := l.entries[w]
+	return ts, ok && len(ts) > 0
+}
+
+// Entries returns the words of the lexicon with their transcripts.
+func (l *Lexicon) Entries() map[string][]string {
+	out := make(map[string][]string)
+	for w, ts := range l.entries {
+		out[w] = append([]string{}, ts...)
+	}
+	return out
+}
+
+// Transcribe returns transcripts of the word w listed in the lexicon. It fails
+// with ErrUnknownWord if the word is not listed.
+func (l *Lexicon) Transcribe(w string, all bool) ([]string, error) {
+	ts, ok := l.entries[l.key(w)]
+	switch {
+	case strings.TrimSpace(w) == "":
+		return []string{}, ErrEmptyInput
+	case !ok:
+		return []string{}, fmt.Errorf("%w %s", ErrUnknownWord, w)
+	case all:
+		return append([]string{}, ts...), nil
+	}
+	return []string{ts[0]}, nil
+}
+
+// Align returns the word w as a single segment with all its transcripts.
+func (l *Lexicon) Align(w string) ([]Segment, error) {
+	ts, err := l.Transcribe(w, true)
+	if err != nil {
+		return []Segment{}, err
+	}
+	return []Segment{{Grapheme: l.key(w), Phonemes: ts}}, nil
+}
+
+// Inventory returns the sorted phonemes of transcripts in the lexicon.
+func (l *Lexicon) Inventory() []string {
+	seen := make(map[string]bool)
+	if l == nil {
+		return []string{}
+	}
+	for _, ts := range l.entries {
+		for _, t := range ts {
+			for _, p := range strings.Fields(t) {
+				seen[p] = true
+			}
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// Chain is the transcriber trying transcribers in order, for example a user
+// lexicon, then rules and then a fallback, until one of them transcribes the
+// word.
+type Chain []Transcriber
+
+// Transcribe returns transcripts of the word w from the first transcriber of
+// the chain that transcribes it. If none does, the returned error joins
+// their errors.
+func (c Chain) Transcribe(w string, all bool) ([]string, error) {
+	var errs []error
+	for _, t := range c {
+		out, err := t.Transcribe(w, all)
+		if err == nil {
+			return out, nil
+		}
+		errs = append(errs, err)
+	}
+	return []string{}, c.failed(errs)
+}
+
+// Align returns segments of the word w from the first transcriber of the
+// chain that aligns it.
+func (c Chain) Align(w string) ([]Segment, error) {
+	var errs []error
+	for _, t := range c {
+		out, err := t.Align(w)
+		if err == nil {
+			return out, nil
+		}
+		errs = append(errs, err)
+	}
+	return []Segment{}, c.failed(errs)
+}
+
+// Inventory returns the sorted phonemes output by any of the transcribers.
+func (c Chain) Inventory() []string {
+	seen := make(map[string]bool)
+	for _, t := range c {
+		for _, p := range t.Inventory() {
+			seen[p] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// failed returns the error joining errs of all transcribers of the chain.
+func (c Chain) failed(errs []error) error {
+	if len(errs) == 0 {
+		return ErrNotLoaded
+	}
+	return errors.Join(errs...)
+}
+
+// sortedKeys returns the keys of the set in order.
+func sortedKeys(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
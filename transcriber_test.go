@@ -0,0 +1,140 @@
+package prg2p
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Transcribers of the package.
+var (
+	_ Transcriber = (*G2P)(nil)
+	_ Transcriber = (*Lexicon)(nil)
+	_ Transcriber = Chain{}
+)
+
+// mock is the transcriber returning the same transcript for every word.
+type mock string
+
+func (m mock) Transcribe(w string, all bool) ([]string, error) {
+	return []string{string(m)}, nil
+}
+
+func (m mock) Align(w string) ([]Segment, error) {
+	return []Segment{{Grapheme: w, Phonemes: []string{string(m)}}}, nil
+}
+
+func (m mock) Inventory() []string {
+	return strings.Fields(string(m))
+}
+
+// Test if the lexicon is read with variants on separate lines.
+func TestReadLexicon(t *testing.T) {
+	src := "# Exceptions\nChleb\th l e p\nchleb\th  l e b\nchleb\th l e p\n\nTOKYO\tt o k j o\n"
+	lex, err := ReadLexicon(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("failed to read lexicon: %s", err)
+	}
+	want := map[string][]string{"chleb": {"h l e p", "h l e b"}, "tokyo": {"t o k j o"}}
+	if ok := reflect.DeepEqual(lex.Entries(), want); !ok {
+		t.Errorf("have %v; want: %v", lex.Entries(), want)
+	}
+	for _, src := range []string{"chleb", "chleb\t ", "\th l e b"} {
+		if _, err := ReadLexicon(strings.NewReader(src)); err == nil {
+			t.Errorf("%q should cause an error", src)
+		}
+	}
+	if _, err := ReadLexicon(nil); err == nil {
+		t.Error("nil reader should cause an error")
+	}
+}
+
+// Test if the lexicon transcribes and aligns only words it lists.
+func TestLexicon(t *testing.T) {
+	lex := NewLexicon(map[string][]string{"Tokyo": {"t o k j o", "t o k i o"}})
+	have, err := lex.Transcribe("TOKYO", false)
+	if err != nil || !reflect.DeepEqual(have, []string{"t o k j o"}) {
+		t.Errorf("have %v, %v; want: [t o k j o]", have, err)
+	}
+	segs, err := lex.Align("tokyo")
+	want := []Segment{{Grapheme: "tokyo", Phonemes: []string{"t o k j o", "t o k i o"}}}
+	if err != nil || !reflect.DeepEqual(segs, want) {
+		t.Errorf("have %v, %v; want: %v", segs, err, want)
+	}
+	if _, err := lex.Transcribe("kyoto", false); !errors.Is(err, ErrUnknownWord) {
+		t.Errorf("have %v; want: %v", err, ErrUnknownWord)
+	}
+	if inv := lex.Inventory(); !reflect.DeepEqual(inv, []string{"i", "j", "k", "o", "t"}) {
+		t.Errorf("have %v; want: [i j k o t]", inv)
+	}
+}
+
+// Test if G2P looks up the lexicon set with WithLexicon in all methods of
+// the Transcriber interface.
+func TestG2PLexicon(t *testing.T) {
+	lex := NewLexicon(map[string][]string{"Tōkyō": {"t o: k j o:"}})
+	g2p, err := Load(Rules(), WithLexicon(lex))
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	have, err := g2p.Transcribe("TŌKYŌ", true)
+	if err != nil || !reflect.DeepEqual(have, []string{"t o: k j o:"}) {
+		t.Errorf("have %v, %v; want: [t o: k j o:]", have, err)
+	}
+	segs, err := g2p.Align("Tōkyō")
+	want := []Segment{{Grapheme: "tōkyō", Phonemes: []string{"t o: k j o:"}}}
+	if err != nil || !reflect.DeepEqual(segs, want) {
+		t.Errorf("have %v, %v; want: %v", segs, err, want)
+	}
+	ds, err := g2p.Derive("tōkyō")
+	if err != nil || len(ds) != 1 || ds[0].Output != "t o: k j o:" {
+		t.Errorf("have %v, %v; want the lexicon transcript", ds, err)
+	}
+	inv := g2p.Inventory()
+	if !contains(inv, "o:") || !contains(inv, "sz") {
+		t.Errorf("have %v; want phonemes of rules and the lexicon", inv)
+	}
+}
+
+// Test if the chain tries transcribers in order.
+func TestChain(t *testing.T) {
+	g2p, err := Load(Rules())
+	if err != nil {
+		t.Fatal("failed to create G2P transcriber")
+	}
+	lex := NewLexicon(map[string][]string{"tokyo": {"t o k j o"}})
+	chain := Chain{lex, g2p, mock("?")}
+	cases := []struct {
+		word string
+		want []string
+	}{
+		{"Tokyo", []string{"t o k j o"}},
+		{"kota", []string{"k o t a"}},
+		{"x$", []string{"?"}},
+	}
+	for _, c := range cases {
+		have, err := chain.Transcribe(c.word, true)
+		if err != nil {
+			t.Fatalf("failed to transcribe %s: %s", c.word, err)
+		}
+		if ok := reflect.DeepEqual(have, c.want); !ok {
+			t.Errorf("have %v; want: %v", have, c.want)
+		}
+	}
+	segs, err := chain.Align("kot")
+	if err != nil || len(segs) != 3 || segs[0].Rule == "" {
+		t.Errorf("have %v, %v; want segments of rules", segs, err)
+	}
+	_, err = Chain{lex, g2p}.Transcribe("x$", false)
+	if !errors.Is(err, ErrUnknownWord) || !errors.Is(err, ErrNoRule) {
+		t.Errorf("have %v; want errors of both transcribers", err)
+	}
+	if _, err := (Chain{}).Transcribe("kot", false); !errors.Is(err, ErrNotLoaded) {
+		t.Errorf("have %v; want: %v", err, ErrNotLoaded)
+	}
+	inv := chain.Inventory()
+	if !contains(inv, "?") || !contains(inv, "dzi") || !contains(inv, "j") {
+		t.Errorf("inventory should join phonemes of all transcribers: %v", inv)
+	}
+}
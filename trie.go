@@ -1,6 +1,7 @@
 package prg2p
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -10,6 +11,7 @@ type trieNode struct {
 	left, right map[string]*trieNode
 	output      []string
 	nchars      int
+	rule        *rule // Rule that set the output.
 }
 
 // traverseLeft traverses the left context of the trieNode. This method can
@@ -47,16 +49,73 @@ func (t *trieNode) traverseRight(s string) *trieNode {
 	return curr
 }
 
-// setOutput sets the character count of source and the output word.
-func (t *trieNode) setOutput(nchars int, out []string) {
-	if t.nchars > nchars {
-		return
+// setOutput sets the character count of source and the output word of the
+// rule r unless the rule that already set the output of the node takes
+// precedence over r. It returns the rule that lost the conflict, nil if there
+// was no conflict, and the reason why it lost.
+func (t *trieNode) setOutput(nchars int, r *rule) (*rule, string) {
+	if t.rule == nil {
+		t.nchars, t.output, t.rule = nchars, r.target, r
+		return nil, ""
 	}
-	var i int
-	i, t.nchars, t.output = t.nchars, nchars, out
-	if i > 0 {
-		return
+	ok, reason := precedes(t.rule, t.nchars, r, nchars)
+	if ok {
+		return r, reason
 	}
+	prev := t.rule
+	t.nchars, t.output, t.rule = nchars, r.target, r
+	return prev, reason
+}
+
+// Reasons for which one rule takes precedence over another rule with the
+// same source and context in the order they are considered.
+const (
+	byPriority    = "priority"
+	bySource      = "source length"
+	bySpecificity = "context specificity"
+	byOrder       = "rule order"
+)
+
+// precedes reports whether the rule a with the source of na characters takes
+// precedence over the rule b with the source of nb characters, where a was
+// inserted before b, and returns the reason. A rule precedes another if it
+// has higher priority, then longer source, then more specific context, that
+// is, the one listing fewer alternatives. Otherwise the later rule wins.
+func precedes(a *rule, na int, b *rule, nb int) (bool, string) {
+	switch {
+	case a.priority != b.priority:
+		return a.priority > b.priority, byPriority
+	case na != nb:
+		return na > nb, bySource
+	case a.specificity() != b.specificity():
+		return a.specificity() < b.specificity(), bySpecificity
+	}
+	return false, byOrder
+}
+
+// Overwrite reports a conflict between two rules that match the same source
+// in the same context while the tree is built. Kept is the rule that
+// transcribes Source between Left and Right contexts and Dropped is the rule
+// that lost the conflict for the Reason such as priority or rule order.
+type Overwrite struct {
+	Source, Left, Right string
+	Kept, Dropped       Segment
+	Reason              string
+}
+
+// String returns a human-readable description of the overwrite.
+func (o Overwrite) String() string {
+	origin := func(s Segment) string {
+		rule := strings.ReplaceAll(s.Rule, "\t", "  ")
+		if s.File != "" {
+			return fmt.Sprintf("%s:%d: %s", s.File, s.Line, rule)
+		}
+		return fmt.Sprintf("line %d: %s", s.Line, rule)
+	}
+	return fmt.Sprintf(
+		"%s[%s]%s: %s overrides %s by %s",
+		o.Left, o.Source, o.Right, origin(o.Kept), origin(o.Dropped), o.Reason,
+	)
 }
 
 // reverse a string.
@@ -75,29 +134,58 @@ func reverse(s string) string {
 // structure starting at the character and going to the right and then left
 // context.
 func newTree(i *interpreter) *trieNode {
+	t, _ := buildTree(i)
+	return t
+}
+
+// buildTree creates a new tree like newTree and returns overwrites that
+// happened while it was built.
+func buildTree(i *interpreter) (*trieNode, []Overwrite) {
 	t := &trieNode{
 		left:  make(map[string]*trieNode),
 		right: make(map[string]*trieNode),
 	}
 	if i == nil {
-		return nil
+		return nil, nil
 	}
-	for _, r := range i.rules {
-		l, r, src, tgt := r.left, r.right, r.source, r.target
-		tierOne := t.traverseRight(src)
-		if l == nil {
-			l = []string{""}
-		}
-		if r == nil {
-			r = []string{""}
-		}
-		for _, tkn := range r {
-			tierTwo := tierOne.traverseRight(tkn)
-			for _, tkn := range l {
-				tierThree := tierTwo.traverseLeft(tkn)
-				tierThree.setOutput(len([]rune(src)), tgt)
+	var out []Overwrite
+	for k := range i.rules {
+		out = append(out, t.insert(&i.rules[k])...)
+	}
+	return t, out
+}
+
+// insert adds the rule r to the tree with the source character(s) on top
+// followed by the right and left context. It returns overwrites that
+// happened while the rule was inserted.
+func (t *trieNode) insert(r *rule) []Overwrite {
+	l, rr, src := r.left, r.right, r.source
+	tierOne := t.traverseRight(src)
+	if l == nil {
+		l = []string{""}
+	}
+	if rr == nil {
+		rr = []string{""}
+	}
+	var out []Overwrite
+	for _, rTkn := range rr {
+		tierTwo := tierOne.traverseRight(rTkn)
+		for _, lTkn := range l {
+			tierThree := tierTwo.traverseLeft(lTkn)
+			lost, reason := tierThree.setOutput(len([]rune(src)), r)
+			kept := tierThree.rule
+			if lost == nil || lost == kept {
+				continue
 			}
+			out = append(out, Overwrite{
+				Source:  src,
+				Left:    lTkn,
+				Right:   rTkn,
+				Kept:    kept.segment(),
+				Dropped: lost.segment(),
+				Reason:  reason,
+			})
 		}
 	}
-	return t
+	return out
 }
@@ -1,6 +1,9 @@
 package prg2p
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 // Test return empty trieNode on nil pointer.
 func TestNilPointer(t *testing.T) {
@@ -9,3 +12,69 @@ func TestNilPointer(t *testing.T) {
 		t.Errorf("nil *Interpreter pointer should result in nil tree pointer")
 	}
 }
+
+// Test the order in which conflicts between rules are resolved.
+func TestPrecedes(t *testing.T) {
+	cases := []struct {
+		name   string
+		a, b   rule
+		na, nb int
+		want   bool
+		reason string
+	}{
+		{"priority", rule{priority: 1}, rule{}, 1, 2, true, byPriority},
+		{"priority-later", rule{}, rule{priority: 1}, 1, 1, false, byPriority},
+		{"source", rule{}, rule{}, 2, 1, true, bySource},
+		{"source-later", rule{}, rule{}, 1, 2, false, bySource},
+		{"specificity", rule{right: []string{"b"}}, rule{right: []string{"b", "p"}}, 1, 1, true, bySpecificity},
+		{"order", rule{right: []string{"b"}}, rule{right: []string{"p"}}, 1, 1, false, byOrder},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			have, reason := precedes(&c.a, c.na, &c.b, c.nb)
+			if have != c.want || reason != c.reason {
+				t.Errorf("have %v by %s; want %v by %s", have, reason, c.want, c.reason)
+			}
+		})
+	}
+}
+
+// Test if overwrites are reported while the tree is built.
+func TestBuildTreeOverwrites(t *testing.T) {
+	i := newInterpreter()
+	r := strings.NewReader(`ALL = a, b, p
+PUSTY = *
+PUSTY	b	(a)	p
+PUSTY	b	(a, p)	b
+@priority 10
+PUSTY	b	(p)	p
+@priority 0
+PUSTY	b	(p)	b`)
+	if err := i.scan(r); err != nil {
+		t.Fatalf("%v", err)
+	}
+	tree, have := buildTree(i)
+	want := []struct {
+		kept, dropped int
+		reason        string
+	}{
+		{3, 4, bySpecificity},
+		{6, 4, byPriority},
+		{6, 8, byPriority},
+	}
+	if len(have) != len(want) {
+		t.Fatalf("have %d overwrites; want %d", len(have), len(want))
+	}
+	for k, w := range want {
+		o := have[k]
+		if o.Kept.Line != w.kept || o.Dropped.Line != w.dropped || o.Reason != w.reason {
+			t.Errorf("have %s; want line %d overriding line %d by %s", o, w.kept, w.dropped, w.reason)
+		}
+	}
+	if n := tree.right["b"].right["a"]; n.rule.line != 3 {
+		t.Errorf("have rule from line %d; want 3", n.rule.line)
+	}
+	if n := tree.right["b"].right["p"]; n.rule.line != 6 {
+		t.Errorf("have rule from line %d; want 6", n.rule.line)
+	}
+}